@@ -104,6 +104,39 @@ func (b *Binding) Validate(value interface{}) error {
 	return b.vd.Validate(value)
 }
 
+// Prepare eagerly registers the struct types of the receivers and warms up the binding.
+// NOTE:
+//  The types can be struct, struct pointer, reflect.Type or reflect.Value;
+//  If there are invalid tags or receiver types, return tagexpr.TagErrors listing every one of them.
+func (b *Binding) Prepare(types ...interface{}) error {
+	var errs tagexpr.TagErrors
+	for _, i := range types {
+		err := b.vd.VM().Register(i)
+		if err != nil {
+			errs = append(errs, err.(tagexpr.TagErrors)...)
+			continue
+		}
+		var t reflect.Type
+		switch v := i.(type) {
+		case reflect.Type:
+			t = v
+		case reflect.Value:
+			t = v.Type()
+		default:
+			t = reflect.TypeOf(i)
+		}
+		t = ameda.DereferenceType(t)
+		_, err = b.getOrPrepareReceiver(reflect.New(t).Elem())
+		if err != nil {
+			errs = append(errs, &tagexpr.TagError{Type: t.String(), Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 func (b *Binding) bind(pointer interface{}, req Request, pathParams PathParams) (elemValue reflect.Value, hasVd bool, err error) {
 	elemValue, err = b.receiverValueOf(pointer)
 	if err != nil {
@@ -163,6 +196,7 @@ func (b *Binding) bindStruct(structPointer interface{}, structValue reflect.Valu
 	if err != nil {
 		return
 	}
+	defer expr.Release()
 
 	bodyCodec, bodyBytes, err := recv.getBodyInfo(req)
 	if len(bodyBytes) > 0 {
@@ -20,6 +20,7 @@ import (
 	"github.com/henrylee2cn/goutil/httpbody"
 	"github.com/stretchr/testify/assert"
 
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
 	"github.com/bytedance/go-tagexpr/v2/binding"
 )
 
@@ -476,6 +477,35 @@ func BenchmarkBindJSON(b *testing.B) {
 	}
 }
 
+func BenchmarkBindAndValidate(b *testing.B) {
+	type Recv struct {
+		A string `query:"a" vd:"len($)>0"`
+		B int    `query:"b" vd:"$>0 && $<100"`
+		C string `header:"c" vd:"$!=''"`
+	}
+	binder := binding.New(nil)
+	header := make(http.Header)
+	header.Set("c", "c1")
+	query := make(url.Values)
+	query.Set("a", "a1")
+	query.Set("b", "2")
+	req := newRequest("http://localhost/?"+query.Encode(), header, nil, nil)
+	recv := new(Recv)
+	if err := binder.BindAndValidate(recv, req, nil); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		*recv = Recv{}
+		if err := binder.BindAndValidate(recv, req, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkStdJSON(b *testing.B) {
 	type Recv struct {
 		X **struct {
@@ -1196,3 +1226,29 @@ func TestVdTagRecursion(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Less(t, int64(time.Since(start)), int64(time.Second))
 }
+
+func TestPrepare(t *testing.T) {
+	type Good struct {
+		A string `query:"a" vd:"len($)>0"`
+	}
+	type Bad struct {
+		A string `query:"a" vd:"len("`
+		B int    `vd:"(("`
+	}
+	binder := binding.New(nil)
+	assert.NoError(t, binder.Prepare(Good{}, new(Good)))
+	err := binder.Prepare(&Good{}, &Bad{}, map[string]string{})
+	errs, ok := err.(tagexpr.TagErrors)
+	if !assert.True(t, ok, err) {
+		return
+	}
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "binding_test.Bad.A: syntax error: \"(\"", errs[0].Error())
+	assert.Equal(t, "binding_test.Bad.B: syntax error: \"((\"", errs[1].Error())
+	assert.Equal(t, "map[string]string: unsupport type: map[string]string", errs[2].Error())
+
+	req := newRequest("http://localhost/?a=x", nil, nil, nil)
+	recv := new(Good)
+	assert.NoError(t, binder.BindAndValidate(recv, req, nil))
+	assert.Equal(t, "x", recv.A)
+}
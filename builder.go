@@ -0,0 +1,148 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/henrylee2cn/ameda"
+)
+
+// StructRules the rule builder of the struct type
+type StructRules struct {
+	vm         *VM
+	structType reflect.Type
+	typeName   string
+	err        error
+}
+
+// FieldRules the rule builder of the struct field
+type FieldRules struct {
+	*StructRules
+	fieldSelector string
+}
+
+var errRegistered = errors.New("struct type is already registered")
+
+// For returns the rule builder of the struct type,
+// e.g. vm.For(&User{}).Field("Email").Rule("@", "email($)").Rule("msg", "'bad email'").
+// NOTE:
+//  The @structOrStructPtrOrType can be struct, struct pointer, reflect.Type or reflect.Value;
+//  The rules are merged with the struct tags when the struct type is registered, see AddRules;
+//  If the struct type is already registered, Err returns an error.
+func (vm *VM) For(structOrStructPtrOrType interface{}) *StructRules {
+	r := &StructRules{vm: vm}
+	t := typeOf(structOrStructPtrOrType)
+	if t == nil {
+		r.err = unsupportNil
+		return r
+	}
+	r.structType, r.err = vm.getStructType(t)
+	if r.err != nil {
+		return r
+	}
+	r.typeName = r.structType.String()
+	if r.structType.Name() != "" {
+		r.typeName = r.structType.PkgPath() + "." + r.structType.Name()
+	}
+	vm.rw.RLock()
+	_, registered := vm.structJar[ameda.RuntimeTypeID(r.structType)]
+	vm.rw.RUnlock()
+	if registered {
+		r.err = fmt.Errorf("%w: %s", errRegistered, r.typeName)
+	}
+	return r
+}
+
+// Field returns the rule builder of the field.
+// NOTE:
+//  The field selector is relative to the struct type, such as 'Email', 'Addr.City' or 'Base.ID' of the embedded Base;
+//  If the field does not exist, Err returns an error.
+func (r *StructRules) Field(fieldSelector string) *FieldRules {
+	f := &FieldRules{StructRules: r, fieldSelector: fieldSelector}
+	if r.err == nil && !hasField(r.structType, fieldSelector) {
+		r.err = fmt.Errorf("%w: %s.%s", ErrFieldSelector, r.typeName, fieldSelector)
+	}
+	return f
+}
+
+// Rule adds the expression to the field.
+// NOTE:
+//  The default expression name is '@';
+//  If the expression is invalid, Err returns an error, and the subsequent rules are ignored.
+func (f *FieldRules) Rule(exprName, expr string) *FieldRules {
+	if f.err != nil {
+		return f
+	}
+	if exprName == "" {
+		exprName = DefaultExprName
+	}
+	source := "rule builder"
+	if _, file, line, ok := runtime.Caller(1); ok {
+		source = fmt.Sprintf("%s:%d", file, line)
+	}
+	r := &fieldRule{
+		RuleError: RuleError{
+			Source:   source,
+			Type:     f.typeName,
+			Field:    f.fieldSelector,
+			ExprName: exprName,
+		},
+		exprString: expr,
+	}
+	var err error
+	r.expr, err = parseExpr(expr)
+	if err != nil {
+		r.Err = err
+		f.err = &r.RuleError
+		return f
+	}
+	f.vm.rw.Lock()
+	f.vm.addRuleLocked(r)
+	f.vm.rw.Unlock()
+	return f
+}
+
+// Err returns the first error of the rule builder.
+func (r *StructRules) Err() error {
+	return r.err
+}
+
+// hasField returns whether the field selector resolves to a field of the struct type.
+func hasField(structType reflect.Type, fieldSelector string) bool {
+	t := structType
+	for _, name := range strings.Split(fieldSelector, FieldSeparator) {
+		t = derefType(t)
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		var found bool
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.Name == name {
+				t = field.Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytedance/go-tagexpr/v2"
+)
+
+type builderBase struct {
+	ID int
+}
+
+type builderUser struct {
+	builderBase
+	Email string
+	Addr  *ruleAddr
+}
+
+func TestRuleBuilder(t *testing.T) {
+	vm := tagexpr.New("te")
+	r := vm.For(&builderUser{}).
+		Field("Email").Rule("@", "regexp('@')").Rule("msg", "'bad email'").
+		Field("builderBase.ID").Rule("", "$>0").
+		Field("Addr.City").Rule("@", "$!='' && (Street)$!=''")
+	assert.NoError(t, r.Err())
+
+	te := vm.MustRun(&builderUser{builderBase: builderBase{ID: 1}, Email: "x", Addr: &ruleAddr{City: "c"}})
+	assert.Equal(t, false, te.Eval("Email"))
+	assert.Equal(t, "bad email", te.Eval("Email@msg"))
+	assert.Equal(t, true, te.Eval("builderBase.ID"))
+	assert.Equal(t, false, te.Eval("Addr.City"))
+
+	// errors
+	assert.EqualError(t, vm.For(builderUser{}).Field("Email").Rule("@", "$").Err(),
+		"struct type is already registered: github.com/bytedance/go-tagexpr/v2_test.builderUser")
+	vm = tagexpr.New("te")
+	err := vm.For(reflect.TypeOf(builderUser{})).Field("ID").Rule("@", "$").Err()
+	assert.True(t, errors.Is(err, tagexpr.ErrFieldSelector), err)
+	assert.EqualError(t, vm.For(1).Err(), "unsupport type: int")
+	err = vm.For(&builderUser{}).Field("Email").Rule("@", "((").Rule("msg", "'x'").Err()
+	var ruleErr *tagexpr.RuleError
+	if assert.True(t, errors.As(err, &ruleErr)) {
+		assert.Contains(t, ruleErr.Source, "builder_test.go:")
+		assert.Equal(t, "@", ruleErr.ExprName)
+	}
+	te = vm.MustRun(&builderUser{})
+	_, err = te.EvalE("Email@msg")
+	assert.Equal(t, tagexpr.ErrExprSelector, err)
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache the LRU cache of the values built from the string keys,
+// the build failures are cached too, so that they are not rebuilt on every call.
+type lruCache struct {
+	mu          sync.Mutex
+	size        int
+	defaultSize int
+	list        *list.List
+	items       map[string]*list.Element
+	build       func(key string) (interface{}, error)
+}
+
+type lruCacheEntry struct {
+	key string
+	val interface{}
+	err error
+}
+
+func newLRUCache(size, defaultSize int, build func(key string) (interface{}, error)) *lruCache {
+	if size <= 0 {
+		size = defaultSize
+	}
+	return &lruCache{
+		size:        size,
+		defaultSize: defaultSize,
+		list:        list.New(),
+		items:       make(map[string]*list.Element, size),
+		build:       build,
+	}
+}
+
+func (c *lruCache) get(key string) (interface{}, error) {
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		c.list.MoveToFront(elem)
+		entry := elem.Value.(*lruCacheEntry)
+		c.mu.Unlock()
+		return entry.val, entry.err
+	}
+	c.mu.Unlock()
+	val, err := c.build(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		// built by another goroutine
+		c.list.MoveToFront(elem)
+		entry := elem.Value.(*lruCacheEntry)
+		return entry.val, entry.err
+	}
+	c.items[key] = c.list.PushFront(&lruCacheEntry{key: key, val: val, err: err})
+	c.evictLocked()
+	return val, err
+}
+
+func (c *lruCache) resize(size int) {
+	if size <= 0 {
+		size = c.defaultSize
+	}
+	c.mu.Lock()
+	c.size = size
+	c.evictLocked()
+	c.mu.Unlock()
+}
+
+func (c *lruCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.list.Len()
+}
+
+func (c *lruCache) evictLocked() {
+	for c.list.Len() > c.size {
+		elem := c.list.Back()
+		c.list.Remove(elem)
+		delete(c.items, elem.Value.(*lruCacheEntry).key)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCache(t *testing.T) {
+	defer SetQueryCacheSize(0)
+	SetQueryCacheSize(2)
+	type T struct{ A int }
+	list := []T{{A: 1}, {A: 2}, {A: 3}}
+	for i := 0; i < 10; i++ {
+		n, err := Count(list, "(A)$>"+strconv.Itoa(i))
+		assert.NoError(t, err)
+		want := 3 - i
+		if want < 0 {
+			want = 0
+		}
+		assert.Equal(t, want, n)
+	}
+	assert.Equal(t, 2, queryCache.len())
+	c1, err := compileQuery("(A)$>9")
+	assert.NoError(t, err)
+	c2, _ := compileQuery("(A)$>9")
+	assert.True(t, c1 == c2)
+
+	_, err = compileQuery("((A)$")
+	assert.Error(t, err)
+	_, err2 := compileQuery("((A)$")
+	assert.Equal(t, err, err2, "the failures are cached too")
+	assert.Equal(t, 2, queryCache.len())
+
+	SetQueryCacheSize(0)
+	queryCache.mu.Lock()
+	assert.Equal(t, DefaultQueryCacheSize, queryCache.size)
+	queryCache.mu.Unlock()
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrUnknownFunc the function is not registered
+	ErrUnknownFunc = errors.New("unknown function")
+	// ErrFuncArity the number of the function arguments is wrong
+	ErrFuncArity = errors.New("wrong number of function arguments")
+)
+
+var funcCallRegexp = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)[ \t]*\(`)
+
+// checkExpr statically checks the expression, @hasField reports whether the field selector resolves.
+// NOTE:
+//  The expression is parsed, the field selectors are resolved,
+//  and the functions and their numbers of arguments are checked.
+func checkExpr(exprString string, hasField func(fieldSelector string) bool) error {
+	expr, err := parseExpr(exprString)
+	if err != nil {
+		if funcName := unknownFunc(exprString); funcName != "" {
+			return fmt.Errorf("%w: %s", ErrUnknownFunc, funcName)
+		}
+		return err
+	}
+	walkExprNode(expr.expr, func(e ExprNode) bool {
+		switch t := e.(type) {
+		case *selectorExprNode:
+			if t.field != "" && !hasField(t.field) {
+				err = fmt.Errorf("%w: (%s)$", ErrFieldSelector, t.field)
+			}
+		case *funcExprNode:
+			err = checkFuncArity(t.name, t.args)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// checkFuncArity checks the number of arguments of the function call, see RegFuncWithArity.
+func checkFuncArity(funcName string, args []ExprNode) error {
+	f, ok := funcFns[funcName]
+	if !ok {
+		return nil
+	}
+	arity := f.arity
+	n := len(args)
+	if n == 1 && args[0].RightOperand() == nil {
+		n = 0 // f()
+	}
+	if n < arity[0] || (arity[1] >= 0 && n > arity[1]) {
+		return fmt.Errorf("%w: %s has %d, want %s", ErrFuncArity, funcName, n, formatArity(arity))
+	}
+	return nil
+}
+
+// unknownFunc returns the first unregistered function called outside the string literals of the expression.
+func unknownFunc(exprString string) string {
+	var code strings.Builder
+	var quoted bool
+	for i := 0; i < len(exprString); i++ {
+		switch c := exprString[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '\'':
+			quoted = !quoted
+			code.WriteByte(' ')
+		case !quoted:
+			code.WriteByte(c)
+		}
+	}
+	for _, m := range funcCallRegexp.FindAllStringSubmatch(code.String(), -1) {
+		if _, ok := funcList[m[1]]; !ok {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func formatArity(arity [2]int) string {
+	switch {
+	case arity[0] == arity[1]:
+		return fmt.Sprint(arity[0])
+	case arity[1] < 0:
+		return fmt.Sprintf("at least %d", arity[0])
+	}
+	return fmt.Sprintf("%d to %d", arity[0], arity[1])
+}
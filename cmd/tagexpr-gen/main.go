@@ -0,0 +1,170 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command tagexpr-gen generates the static validation code of the struct tags.
+//
+// It reads the Go package in the directory, and declares a Validate() error method
+// for every struct type that has validation expressions, with the same semantics as the validator package.
+//
+// Usage:
+//
+//	tagexpr-gen [-tag vd] [-type User,Order] [-o tagexpr_gen.go] [-conformance] [dir]
+//
+// With -conformance, it also generates the test file that cross-checks the generated methods
+// against the validator on the sample values.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
+	"github.com/bytedance/go-tagexpr/v2/internal/codegen"
+	"github.com/bytedance/go-tagexpr/v2/internal/gentype"
+	// register the functions of the validator, such as email() and phone()
+	_ "github.com/bytedance/go-tagexpr/v2/validator"
+)
+
+var (
+	tagNames    = flag.String("tag", "vd", "comma-separated struct tag names, the earlier one takes precedence")
+	typeNames   = flag.String("type", "", "comma-separated struct type names, default is all")
+	output      = flag.String("o", "tagexpr_gen.go", "output file name in the package directory")
+	conformance = flag.Bool("conformance", false, "also generate the conformance test file")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tagexpr-gen [flags] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	if err := run(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "tagexpr-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string) error {
+	testOutput := strings.TrimSuffix(*output, ".go") + "_test.go"
+	pkg, err := loadPackage(dir, *output)
+	if err != nil {
+		return err
+	}
+	var only []string
+	if *typeNames != "" {
+		only = strings.Split(*typeNames, ",")
+	}
+	structs, err := namedStructs(pkg, only)
+	if err != nil {
+		return err
+	}
+	vm := tagexpr.New(strings.Split(*tagNames, ",")...)
+	src, err := codegen.GenerateValidate(vm, pkg.Name(), structs)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, *output), src, 0644); err != nil {
+		return err
+	}
+	if !*conformance {
+		return nil
+	}
+	src, err = codegen.GenerateConformanceTest(vm, pkg.Name(), structs)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, testOutput), src, 0644)
+}
+
+// loadPackage parses and type-checks the package in the directory, excluding the generated file.
+func loadPackage(dir, exclude string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		if name == exclude {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	return conf.Check(bp.ImportPath, fset, files, nil)
+}
+
+// namedStructs returns the named struct types of the package in the declaration order.
+func namedStructs(pkg *types.Package, only []string) ([]*gentype.Struct, error) {
+	c := gentype.NewConverter(pkg)
+	scope := pkg.Scope()
+	var names []*types.TypeName
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		if _, ok = tn.Type().Underlying().(*types.Struct); ok {
+			names = append(names, tn)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].Pos() < names[j].Pos() })
+	var a []*gentype.Struct
+	for _, tn := range names {
+		if len(only) > 0 && !contains(only, tn.Name()) {
+			continue
+		}
+		if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(tn.Type()), false, pkg, "Validate"); obj != nil {
+			if len(only) > 0 {
+				return nil, fmt.Errorf("%s already has the field or method Validate", tn.Name())
+			}
+			fmt.Fprintf(os.Stderr, "tagexpr-gen: skip %s, it already has the field or method Validate\n", tn.Name())
+			continue
+		}
+		a = append(a, c.Struct(tn.Type()))
+	}
+	if len(only) > 0 && len(a) != len(only) {
+		return nil, errors.New("some of the types are not the struct types of the package")
+	}
+	return a, nil
+}
+
+func contains(a []string, s string) bool {
+	for _, e := range a {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tagexpr-gen")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	src, err := ioutil.ReadFile("testdata/example/example.go")
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "example.go"), src, 0644))
+
+	*conformance = true
+	defer func() { *conformance = false }()
+	if !assert.NoError(t, run(dir)) {
+		return
+	}
+	// the golden files are the outputs of 'go generate ./testdata/example'
+	for _, name := range []string{"tagexpr_gen.go", "tagexpr_gen_test.go"} {
+		want, err := ioutil.ReadFile(filepath.Join("testdata/example", name))
+		assert.NoError(t, err)
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, string(want), string(got), name)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("it runs the go command")
+	}
+	out, err := exec.Command("go", "test", "./testdata/example").CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
@@ -0,0 +1,52 @@
+// Package example is the input of the tagexpr-gen tests.
+package example
+
+import "time"
+
+//go:generate go run ../.. -conformance
+
+type Status int
+
+type User struct {
+	ID      int64    `vd:"$>0 && $%2==1"`
+	Name    string   `vd:"regexp('^\\w+$') && mblen($)<=5" vd:"msg:sprintf('invalid name: %v', $)"`
+	Nick    string   `vd:"$=='' || len($)>=2; msg:'bad nick'"`
+	Age     uint8    `vd:"$>=18 && $<(Limit)$*2"`
+	Limit   float64  `vd:"$/(Age)$ != 1"`
+	Status  Status   `vd:"$!=2 && $=='0' == false"`
+	Score   float32  `vd:"-$<=0 || $+'1'>3"`
+	Admin   bool     `vd:"!$ || (Age)$>=20"`
+	Tags    []string `vd:"len($)<3"`
+	Addr    Addr
+	Backup  *Addr
+	Orders  []Order
+	Items   map[string]*Order `vd:"?"`
+	Skip    *Addr             `vd:"-"`
+	Created time.Time
+	note    string `vd:"$+'x'!='x' || (Name)$>'b'"`
+}
+
+type Addr struct {
+	City  string `vd:"$=~'^[A-Z]'"`
+	Zip   string `vd:"len($)==0 || $>=10"`
+	Lines [2]Line
+}
+
+type Line struct {
+	Text string `vd:"!regexp('x', $)"`
+}
+
+type Order struct {
+	Qty   int `vd:"$>0"`
+	Price int `vd:"$*(Qty)$<=1000; msg:sprintf('%v too expensive', $*(Qty)$)"`
+}
+
+// Event uses a custom function, so it falls back to the validator at runtime.
+type Event struct {
+	Email string `vd:"email($)"`
+	When  *int   `vd:"$!=nil"`
+}
+
+type Plain struct {
+	X int
+}
@@ -0,0 +1,230 @@
+// Code generated by tagexpr-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/bytedance/go-tagexpr/v2/validator"
+)
+
+var tagexprValidator = validator.New("vd")
+
+var (
+	tagexprRegexp0 = regexp.MustCompile("^\\w+$")
+	tagexprRegexp1 = regexp.MustCompile("^[A-Z]")
+	tagexprRegexp2 = regexp.MustCompile("x")
+)
+
+// Validate validates the fields of User by the struct tags.
+func (x *User) Validate() error {
+	return x.tagexprValidate("")
+}
+
+func (x *User) tagexprValidate(path string) error {
+	if err := x.tagexprValidateFields(path); err != nil {
+		return err
+	}
+	return x.tagexprValidateElems(path)
+}
+
+func (x *User) tagexprValidateFields(path string) error {
+	// ID: $ > 0 && $ % 2 == 1
+	if !(((float64(x.ID)) > (float64(0))) && ((tagexprMod((float64(x.ID)), (float64(2)))) == (float64(1)))) {
+		return &validator.Error{FailPath: path + "ID", Msg: ""}
+	}
+	// Name: regexp('^\w+$', $) && mblen($) <= 5
+	if !((tagexprRegexp0.MatchString(string(x.Name))) && ((float64(utf8.RuneCountInString(string(x.Name)))) <= (float64(5)))) {
+		return &validator.Error{FailPath: path + "Name", Msg: ""}
+	}
+	// Nick: $ == '' || len($) >= 2
+	if !(((string(x.Nick)) == ("")) || ((float64(len(string(x.Nick)))) >= (float64(2)))) {
+		return &validator.Error{FailPath: path + "Nick", Msg: "bad nick"}
+	}
+	// Age: $ >= 18 && $ < (Limit)$ * 2
+	if !(((float64(x.Age)) >= (float64(18))) && ((float64(x.Age)) < ((float64(x.Limit)) * (float64(2))))) {
+		return &validator.Error{FailPath: path + "Age", Msg: ""}
+	}
+	// Limit: $ / (Age)$ != 1
+	if !(!((tagexprDiv((float64(x.Limit)), (float64(x.Age)))) == (float64(1)))) {
+		return &validator.Error{FailPath: path + "Limit", Msg: ""}
+	}
+	// Status: $ != 2 && $ == '0' == false
+	if !((!((float64(x.Status)) == (float64(2)))) && ((func() bool { f, ok := tagexprParseNumber(("0")); return ok && (float64(x.Status)) == f }()) == (false))) {
+		return &validator.Error{FailPath: path + "Status", Msg: ""}
+	}
+	// Score: -$ <= 0 || $ + '1' > 3
+	if !(((-(float64(x.Score))) <= (float64(0))) || (((float64(x.Score)) + tagexprParseFloat(("1"))) > (float64(3)))) {
+		return &validator.Error{FailPath: path + "Score", Msg: ""}
+	}
+	// Admin: !$ || (Age)$ >= 20
+	if !((!(bool(x.Admin))) || ((float64(x.Age)) >= (float64(20)))) {
+		return &validator.Error{FailPath: path + "Admin", Msg: ""}
+	}
+	// Tags: len($) < 3
+	if !((float64(len(x.Tags))) < (float64(3))) {
+		return &validator.Error{FailPath: path + "Tags", Msg: ""}
+	}
+	if err := x.Addr.tagexprValidateFields(path + "Addr."); err != nil {
+		return err
+	}
+	if x.Backup != nil {
+		if err := x.Backup.tagexprValidateFields(path + "Backup."); err != nil {
+			return err
+		}
+	}
+	// note: $ + 'x' != 'x' || (Name)$ > 'b'
+	if !((!(((string(x.note)) + ("x")) == ("x"))) || ((string(x.Name)) > ("b"))) {
+		return &validator.Error{FailPath: path + "note", Msg: ""}
+	}
+	return nil
+}
+
+func (x *User) tagexprValidateElems(path string) error {
+	if err := x.Addr.tagexprValidateElems(path + "Addr."); err != nil {
+		return err
+	}
+	if x.Backup != nil {
+		if err := x.Backup.tagexprValidateElems(path + "Backup."); err != nil {
+			return err
+		}
+	}
+	for i := len(x.Orders) - 1; i >= 0; i-- {
+		if err := x.Orders[i].tagexprValidate(path + "Orders[" + strconv.Itoa(i) + "]."); err != nil {
+			return err
+		}
+	}
+	for k, v := range x.Items {
+		if v == nil {
+			continue
+		}
+		if err := v.tagexprValidate(path + "Items{v for k=" + string(k) + "}."); err != nil {
+			return err
+		}
+	}
+	if x.Skip != nil {
+		if err := x.Skip.tagexprValidateElems(path + "Skip."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates the fields of Addr by the struct tags.
+func (x *Addr) Validate() error {
+	return x.tagexprValidate("")
+}
+
+func (x *Addr) tagexprValidate(path string) error {
+	if err := x.tagexprValidateFields(path); err != nil {
+		return err
+	}
+	return x.tagexprValidateElems(path)
+}
+
+func (x *Addr) tagexprValidateFields(path string) error {
+	// City: $ =~ '^[A-Z]'
+	if !(tagexprRegexp1.MatchString(string(x.City))) {
+		return &validator.Error{FailPath: path + "City", Msg: ""}
+	}
+	// Zip: len($) == 0 || $ >= 10
+	if !(((float64(len(string(x.Zip)))) == (float64(0))) || ((string(x.Zip)) >= fmt.Sprint((float64(10))))) {
+		return &validator.Error{FailPath: path + "Zip", Msg: ""}
+	}
+	return nil
+}
+
+func (x *Addr) tagexprValidateElems(path string) error {
+	for i := len(x.Lines) - 1; i >= 0; i-- {
+		if err := x.Lines[i].tagexprValidate(path + "Lines[" + strconv.Itoa(i) + "]."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates the fields of Line by the struct tags.
+func (x *Line) Validate() error {
+	return x.tagexprValidate("")
+}
+
+func (x *Line) tagexprValidate(path string) error {
+	if err := x.tagexprValidateFields(path); err != nil {
+		return err
+	}
+	return x.tagexprValidateElems(path)
+}
+
+func (x *Line) tagexprValidateFields(path string) error {
+	// Text: !regexp('x', $)
+	if !(!tagexprRegexp2.MatchString(string(x.Text))) {
+		return &validator.Error{FailPath: path + "Text", Msg: ""}
+	}
+	return nil
+}
+
+func (x *Line) tagexprValidateElems(path string) error {
+	return nil
+}
+
+// Validate validates the fields of Order by the struct tags.
+func (x *Order) Validate() error {
+	return x.tagexprValidate("")
+}
+
+func (x *Order) tagexprValidate(path string) error {
+	if err := x.tagexprValidateFields(path); err != nil {
+		return err
+	}
+	return x.tagexprValidateElems(path)
+}
+
+func (x *Order) tagexprValidateFields(path string) error {
+	// Qty: $ > 0
+	if !((float64(x.Qty)) > (float64(0))) {
+		return &validator.Error{FailPath: path + "Qty", Msg: ""}
+	}
+	// Price: $ * (Qty)$ <= 1000
+	if !(((float64(x.Price)) * (float64(x.Qty))) <= (float64(1000))) {
+		return &validator.Error{FailPath: path + "Price", Msg: fmt.Sprintf("%v too expensive", (float64(x.Price))*(float64(x.Qty)))}
+	}
+	return nil
+}
+
+func (x *Order) tagexprValidateElems(path string) error {
+	return nil
+}
+
+// Validate validates the fields of Event by the struct tags.
+// It falls back to the validator at runtime, since Event.Email: unsupported by the code generation: email($).
+func (x *Event) Validate() error {
+	return tagexprValidator.Validate(x)
+}
+
+func tagexprDiv(a, b float64) float64 {
+	if b == 0 {
+		return math.NaN()
+	}
+	return a / b
+}
+
+func tagexprMod(a, b float64) float64 {
+	if b == 0 {
+		return math.NaN()
+	}
+	return float64(int64(a) % int64(b))
+}
+
+func tagexprParseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func tagexprParseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
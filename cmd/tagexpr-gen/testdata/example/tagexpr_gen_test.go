@@ -0,0 +1,121 @@
+// Code generated by tagexpr-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/bytedance/go-tagexpr/v2/validator"
+)
+
+// TestTagexprGenConformance checks that the generated Validate methods return the same errors as the validator.
+func TestTagexprGenConformance(t *testing.T) {
+	vd := validator.New("vd")
+	for _, c := range []struct {
+		name string
+		new  func() interface{ Validate() error }
+	}{
+		{"User", func() interface{ Validate() error } { return new(User) }},
+		{"Addr", func() interface{ Validate() error } { return new(Addr) }},
+		{"Line", func() interface{ Validate() error } { return new(Line) }},
+		{"Order", func() interface{ Validate() error } { return new(Order) }},
+		{"Event", func() interface{ Validate() error } { return new(Event) }},
+	} {
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < tagexprGenSamples; i++ {
+			x := c.new()
+			if i > 0 {
+				tagexprGenFill(r, reflect.ValueOf(x).Elem(), 0)
+			}
+			want, got := vd.Validate(x), x.Validate()
+			if !tagexprGenSameError(want, got) {
+				t.Fatalf("%s: sample %d %+v: the validator returns %v, but Validate returns %v", c.name, i, x, want, got)
+			}
+		}
+	}
+}
+
+const tagexprGenSamples = 10000
+
+var tagexprGenPkgPath = reflect.TypeOf((*User)(nil)).Elem().PkgPath()
+
+// the sample values, including the literals of the expressions and their neighbors
+var (
+	tagexprGenInts    = []int64{-1, 0, 1, 2, 3, 4, 5, 6, 9, 10, 11, 17, 18, 19, 20, 21, 100, 999, 1000, 1001}
+	tagexprGenFloats  = []float64{-1.5, -1, -0.5, 0, 0.5, 1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6, 9, 9.5, 10, 10.5, 11, 17, 17.5, 18, 18.5, 19, 19.5, 20, 20.5, 21, 999, 999.5, 1000, 1000.5, 1001}
+	tagexprGenStrings = []string{"", "-3", "0", "0a", "1", "10", "1000", "18", "1a", "2", "2.5", "20", "3", "5", "Hello World", "^[A-Z]", "^[A-Z]a", "^\\w+$", "^\\w+$a", "a", "ab", "abc", "b", "ba", "bad nick", "bad nicka", "true", "x", "xa", "中文"}
+)
+
+func tagexprGenSameError(want, got error) bool {
+	if want == nil || got == nil {
+		return want == nil && got == nil
+	}
+	if want.Error() != got.Error() {
+		return false
+	}
+	w, ok1 := want.(*validator.Error)
+	g, ok2 := got.(*validator.Error)
+	return ok1 == ok2 && (!ok1 || w.FailPath == g.FailPath)
+}
+
+// tagexprGenFill fills the value with the random samples, the fields of the structs of other packages are skipped.
+func tagexprGenFill(r *rand.Rand, v reflect.Value, depth int) {
+	if !v.CanSet() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(tagexprGenInts[r.Intn(len(tagexprGenInts))])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := tagexprGenInts[r.Intn(len(tagexprGenInts))]
+		if n < 0 {
+			n = -n
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(tagexprGenFloats[r.Intn(len(tagexprGenFloats))])
+	case reflect.String:
+		v.SetString(tagexprGenStrings[r.Intn(len(tagexprGenStrings))])
+	case reflect.Ptr:
+		if depth < 3 && r.Intn(3) > 0 {
+			e := reflect.New(v.Type().Elem())
+			tagexprGenFill(r, e.Elem(), depth+1)
+			v.Set(e)
+		}
+	case reflect.Slice:
+		if depth < 3 && r.Intn(3) > 0 {
+			n := r.Intn(4)
+			s := reflect.MakeSlice(v.Type(), n, n)
+			for i := 0; i < n; i++ {
+				tagexprGenFill(r, s.Index(i), depth+1)
+			}
+			v.Set(s)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			tagexprGenFill(r, v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		// at most one entry, since the validator ranges over the map in random order
+		if depth < 3 && r.Intn(2) == 0 {
+			m := reflect.MakeMap(v.Type())
+			k, e := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+			tagexprGenFill(r, k, depth+1)
+			tagexprGenFill(r, e, depth+1)
+			m.SetMapIndex(k, e)
+			v.Set(m)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" && v.Type().PkgPath() != tagexprGenPkgPath {
+				continue
+			}
+			tagexprGenFill(r, v.Field(i), depth+1)
+		}
+	}
+}
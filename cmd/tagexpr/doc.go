@@ -0,0 +1,37 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command tagexpr tries the tag expressions against the JSON input.
+//
+// Usage:
+//
+//	tagexpr eval [-field selector] [-explain] expr [input.json]
+//	tagexpr check [-tree] expr...
+//	tagexpr validate [-type name] [-all] [-explain] rules.json|rules.yaml [input.json]
+//
+// The JSON object is treated as a struct, its keys are the field names,
+// e.g. (addr.city)$ selects the city of {"addr":{"city":"x"}}.
+// The numbers are float64, the arrays are slices, and the keys that are not Go identifiers are ignored.
+// The input is read from the standard input if the file is omitted or is '-'.
+//
+// eval evaluates the expression on the field selected by -field, which can not go through the arrays,
+// '$' is nil if it is omitted.
+//
+// check parses the expressions and prints their normalized text, or the syntax errors.
+//
+// validate runs the rules of the type selected by -type against the input, like the validator package,
+// the format of the rules is the same as tagexpr.Rules, the field selectors may go through the arrays.
+//
+// The exit status is 1 if the expression is invalid or the validation fails, and 2 for the usage errors.
+package main
@@ -0,0 +1,289 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
+	"github.com/bytedance/go-tagexpr/v2/validator"
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `Usage:
+	tagexpr eval [-field selector] [-explain] expr [input.json]
+	tagexpr check [-tree] expr...
+	tagexpr validate [-type name] [-all] [-explain] rules.json|rules.yaml [input.json]
+`
+
+// errFailed the expression is invalid or the validation fails, the details are already printed
+var errFailed = errors.New("failed")
+
+// usageError the wrong command line
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "eval":
+		err = runEval(args[1:], stdin, stdout)
+	case "check":
+		err = runCheck(args[1:], stdout)
+	case "validate":
+		err = runValidate(args[1:], stdin, stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		err = &usageError{fmt.Sprintf("unknown command %q", args[0])}
+	}
+	switch err.(type) {
+	case nil:
+		return 0
+	case *usageError:
+		fmt.Fprintf(stderr, "tagexpr: %v\n%s", err, usage)
+		return 2
+	}
+	if err != errFailed {
+		fmt.Fprintf(stderr, "tagexpr: %v\n", err)
+	}
+	return 1
+}
+
+// parseFlags parses the flags of the command, and checks the number of the remaining arguments.
+func parseFlags(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, &usageError{fmt.Sprintf("%s: %v", fs.Name(), err)}
+	}
+	args = fs.Args()
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		return nil, &usageError{fmt.Sprintf("%s: wrong number of arguments", fs.Name())}
+	}
+	return args, nil
+}
+
+func runEval(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	field := fs.String("field", "", "selector of the field that the expression is on, e.g. addr.city")
+	explain := fs.Bool("explain", false, "print the evaluation trace tree")
+	args, err := parseFlags(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	doc, err := readInput(args[1:], stdin)
+	if err != nil {
+		return err
+	}
+	if *field == "" {
+		if doc.field(evalFieldName) != nil {
+			return fmt.Errorf("the JSON object has the reserved key %s", evalFieldName)
+		}
+		*field = evalFieldName
+	}
+	if err = doc.addExprs(*field, map[string]string{tagexpr.DefaultExprName: args[0]}); err != nil {
+		return err
+	}
+	ptr, err := doc.build()
+	if err != nil {
+		return err
+	}
+	te, err := tagexpr.New(tagName).Run(ptr)
+	if err != nil {
+		return err
+	}
+	v, err := te.EvalE(*field)
+	if err != nil {
+		if *field == evalFieldName {
+			return err
+		}
+		return fmt.Errorf("-field %s: %v", *field, err)
+	}
+	if *explain {
+		fmt.Fprint(stdout, te.Explain(*field))
+		return nil
+	}
+	fmt.Fprintln(stdout, formatValue(v.Interface()))
+	return nil
+}
+
+// evalFieldName the name of the field that the expression is on, if -field is omitted
+const evalFieldName = "Eval_"
+
+// tagName the name of the tags of the built struct
+const tagName = "vd"
+
+func runCheck(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	tree := fs.Bool("tree", false, "print the syntax tree")
+	args, err := parseFlags(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	for _, s := range args {
+		expr, perr := tagexpr.Parse(s)
+		if perr != nil {
+			fmt.Fprintf(stdout, "%q: %v\n", s, perr)
+			err = errFailed
+			continue
+		}
+		if *tree {
+			fmt.Fprint(stdout, expr.Tree())
+		} else {
+			fmt.Fprintln(stdout, expr)
+		}
+	}
+	return err
+}
+
+func runValidate(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	typeName := fs.String("type", "", "type name of the rules, can be omitted if there is only one type")
+	all := fs.Bool("all", false, "report all the failures")
+	explain := fs.Bool("explain", false, "print the failed clauses")
+	args, err := parseFlags(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	fields, err := readRules(args[0], *typeName)
+	if err != nil {
+		return err
+	}
+	doc, err := readInput(args[1:], stdin)
+	if err != nil {
+		return err
+	}
+	for _, fieldSelector := range sortedKeys(fields) {
+		if err = doc.addExprs(fieldSelector, fields[fieldSelector]); err != nil {
+			return err
+		}
+	}
+	ptr, err := doc.build()
+	if err != nil {
+		return err
+	}
+	var failures []*validator.Error
+	vd := validator.New(tagName).SetExplain(*explain).SetErrorFactory(func(failPath, msg string) error {
+		e := &validator.Error{FailPath: failPath, Msg: msg}
+		failures = append(failures, e)
+		return e
+	})
+	err = vd.Validate(ptr, *all)
+	if len(failures) == 0 {
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, "ok")
+		return nil
+	}
+	for _, e := range failures {
+		fmt.Fprintf(stdout, "%s: %s\n", e.FailPath, e.Error())
+		if e.FailClause != nil {
+			fmt.Fprintf(stdout, "\tfailed clause: %s\n", e.FailClause.Source)
+		}
+	}
+	return errFailed
+}
+
+// readRules reads the rules of the type from the JSON or YAML file, see tagexpr.Rules.
+func readRules(filename, typeName string) (map[string]map[string]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var rules tagexpr.Rules
+	switch filepath.Ext(filename) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &rules)
+	default:
+		err = json.Unmarshal(data, &rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if typeName == "" {
+		if len(rules) != 1 {
+			return nil, &usageError{fmt.Sprintf("validate: %s has %d types, select one by -type", filename, len(rules))}
+		}
+		for name := range rules {
+			typeName = name
+		}
+	}
+	fields, ok := rules[typeName]
+	if !ok {
+		return nil, fmt.Errorf("%s: no rules of the type %s", filename, typeName)
+	}
+	return fields, nil
+}
+
+// readInput reads the JSON object from the file, or the standard input if no file or '-'.
+func readInput(args []string, stdin io.Reader) (*document, error) {
+	r := stdin
+	name := "standard input"
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r, name = f, args[0]
+	}
+	doc, err := decodeShape(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return doc, nil
+}
+
+// formatValue formats the result of the expression, the literals are in the expression syntax.
+func formatValue(v interface{}) string {
+	switch r := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return "'" + strings.Replace(r, "'", "\\'", -1) + "'"
+	case error:
+		return "error: " + r.Error()
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runCmd(stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestEval(t *testing.T) {
+	var cases = []struct {
+		args []string
+		want string
+	}{
+		{[]string{"len((name)$)>1 && (Age)$>=18"}, "false"},
+		{[]string{"(addr.city)$"}, "'SH'"},
+		{[]string{"-field", "addr.city", "$=='SH'"}, "true"},
+		{[]string{"len((tags)$)"}, "3"},
+		{[]string{"(note)$==nil && (nope)$==nil"}, "true"},
+		{[]string{"sprintf('%s@%v', (name)$, (Age)$)"}, "'ab@17'"},
+	}
+	for _, c := range cases {
+		code, stdout, stderr := runCmd("", append(append([]string{"eval"}, c.args...), "testdata/user.json")...)
+		assert.Equal(t, 0, code, stderr)
+		assert.Equal(t, c.want+"\n", stdout, c.args)
+	}
+
+	code, stdout, _ := runCmd(`{"a":[1,2]}`, "eval", "-explain", "len((a)$)==2")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "len((a)$) == 2 => true\n  len((a)$) => 2\n    (a)$ => [1 2]\n  2 => 2\n", stdout)
+
+	code, _, stderr := runCmd(`[1]`, "eval", "1")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "tagexpr: standard input: the JSON value is not an object\n", stderr)
+	code, _, stderr = runCmd(`{}`, "eval", "((")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `syntax error: "(("`)
+	code, _, stderr = runCmd(`{"a":[{"b":1}]}`, "eval", "-field", "a.b", "$>0")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "tagexpr: -field a.b: expression selector does not exist\n", stderr)
+	code, _, _ = runCmd(`{}`, "eval")
+	assert.Equal(t, 2, code)
+}
+
+func TestCheck(t *testing.T) {
+	code, stdout, _ := runCmd("", "check", "(a)$>1&&len($)<3", "!$")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "(a)$ > 1 && len($) < 3\n!$\n", stdout)
+
+	code, stdout, _ = runCmd("", "check", "-tree", "$+1")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "$ + 1\n  $\n  1\n", stdout)
+
+	code, stdout, _ = runCmd("", "check", "((", "1")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "\"((\": syntax error: \"((\"\n1\n", stdout)
+}
+
+func TestValidate(t *testing.T) {
+	code, stdout, _ := runCmd("", "validate", "testdata/rules.yaml", "testdata/user.json")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "Age: adult only\n", stdout)
+
+	code, stdout, _ = runCmd("", "validate", "-all", "-explain", "testdata/rules.yaml", "testdata/user.json")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "Age: adult only\n\tfailed clause: $ >= 18\n"+
+		"phone: invalid parameter: phone\n\tfailed clause: $ != nil\n"+
+		"items[1].price: invalid parameter: items[1].price\n\tfailed clause: $ > 0\n", stdout)
+
+	code, stdout, _ = runCmd(`{"name":"ab"}`, "validate", "-type", "User", "testdata/rules.json")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "ok\n", stdout)
+	code, stdout, _ = runCmd(`{"id":null}`, "validate", "-type", "Order", "testdata/rules.json", "-")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "id: invalid parameter: id\n", stdout)
+
+	code, _, stderr := runCmd(`{}`, "validate", "testdata/rules.json")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "select one by -type")
+	code, _, stderr = runCmd(`{"name":1}`, "validate", "-type", "User", "testdata/rules.json")
+	assert.Equal(t, 1, code, stderr)
+	code, _, stderr = runCmd(`{"name":{"x":1}}`, "validate", "-type", "Order", "testdata/rules.json")
+	assert.Equal(t, 1, code, stderr)
+}
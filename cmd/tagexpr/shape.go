@@ -0,0 +1,379 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
+)
+
+type kind int
+
+const (
+	kindNull kind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindObject
+	kindArray
+	kindMixed // the array elements or the object fields of different kinds
+)
+
+// node the JSON value, the object keys keep their order
+type node struct {
+	kind   kind
+	scalar interface{} // bool, float64 or string
+	keys   []string    // object
+	values []*node     // object values or array elements
+}
+
+func (n *node) get(key string) *node {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+// raw returns the value as decoded by encoding/json.
+func (n *node) raw() interface{} {
+	switch n.kind {
+	case kindObject:
+		m := make(map[string]interface{}, len(n.keys))
+		for i, k := range n.keys {
+			m[k] = n.values[i].raw()
+		}
+		return m
+	case kindArray:
+		a := make([]interface{}, len(n.values))
+		for i, e := range n.values {
+			a[i] = e.raw()
+		}
+		return a
+	}
+	return n.scalar
+}
+
+// shape the struct-like type of the JSON values
+type shape struct {
+	kind     kind
+	nullable bool          // null or missing in some values, the Go type is a pointer
+	fields   []*shapeField // object
+	elem     *shape        // array, nil if always empty
+}
+
+type shapeField struct {
+	name  string
+	shape *shape
+	exprs map[string]string // expression name -> expression
+}
+
+func (s *shape) field(name string) *shapeField {
+	for _, f := range s.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// document the JSON object and its shape
+type document struct {
+	*shape
+	root *node
+}
+
+// decodeShape decodes the JSON object.
+func decodeShape(r io.Reader) (*document, error) {
+	dec := json.NewDecoder(r)
+	root, err := decodeNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: data after the top-level value")
+	}
+	if root.kind != kindObject {
+		return nil, errors.New("the JSON value is not an object")
+	}
+	return &document{shape: shapeOf(root), root: root}, nil
+}
+
+func decodeNode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	switch t := tok.(type) {
+	case nil:
+		return &node{kind: kindNull}, nil
+	case bool:
+		return &node{kind: kindBool, scalar: t}, nil
+	case float64:
+		return &node{kind: kindNumber, scalar: t}, nil
+	case string:
+		return &node{kind: kindString, scalar: t}, nil
+	}
+	n := &node{kind: kindArray}
+	if tok == json.Delim('{') {
+		n.kind = kindObject
+	}
+	for dec.More() {
+		if n.kind == kindObject {
+			tok, err = dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON: %v", err)
+			}
+			n.keys = append(n.keys, tok.(string))
+		}
+		v, err := decodeNode(dec)
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, v)
+	}
+	if _, err = dec.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return n, nil
+}
+
+// shapeOf returns the shape of the JSON value,
+// the object keys that are not Go identifiers are ignored.
+func shapeOf(n *node) *shape {
+	s := &shape{kind: n.kind}
+	switch n.kind {
+	case kindNull:
+		s.nullable = true
+	case kindObject:
+		for i, key := range n.keys {
+			if !token.IsIdentifier(key) || key == "_" {
+				continue
+			}
+			if f := s.field(key); f != nil {
+				f.shape = shapeOf(n.values[i]) // the last duplicate key wins
+				continue
+			}
+			s.fields = append(s.fields, &shapeField{name: key, shape: shapeOf(n.values[i])})
+		}
+	case kindArray:
+		for _, e := range n.values {
+			s.elem = mergeShape(s.elem, shapeOf(e))
+		}
+	}
+	return s
+}
+
+// mergeShape returns the shape of the values of both shapes.
+func mergeShape(a, b *shape) *shape {
+	if a == nil {
+		return b
+	}
+	nullable := a.nullable || b.nullable
+	switch {
+	case a.kind == kindNull:
+		a, b = b, a
+		fallthrough
+	case b.kind == kindNull:
+		r := *a
+		r.nullable = true
+		return &r
+	case a.kind != b.kind:
+		return &shape{kind: kindMixed, nullable: nullable}
+	}
+	r := &shape{kind: a.kind, nullable: nullable}
+	switch a.kind {
+	case kindObject:
+		for _, f := range a.fields {
+			s := f.shape
+			if bf := b.field(f.name); bf != nil {
+				s = mergeShape(s, bf.shape)
+			} else {
+				s = mergeShape(s, &shape{kind: kindNull, nullable: true})
+			}
+			r.fields = append(r.fields, &shapeField{name: f.name, shape: s})
+		}
+		for _, f := range b.fields {
+			if a.field(f.name) == nil {
+				r.fields = append(r.fields, &shapeField{name: f.name, shape: mergeShape(f.shape, &shape{kind: kindNull, nullable: true})})
+			}
+		}
+	case kindArray:
+		r.elem = a.elem
+		if b.elem != nil {
+			r.elem = mergeShape(r.elem, b.elem)
+		}
+	}
+	return r
+}
+
+// addExprs adds the expressions to the field, the field selector may go through the arrays,
+// the missing fields are added as nullable.
+func (s *shape) addExprs(fieldSelector string, exprs map[string]string) error {
+	names := strings.Split(fieldSelector, tagexpr.FieldSeparator)
+	for i, name := range names {
+		for s.kind == kindArray {
+			if s.elem == nil {
+				s.elem = &shape{kind: kindNull, nullable: true}
+			}
+			s = s.elem
+		}
+		switch s.kind {
+		case kindNull:
+			s.kind = kindObject
+		case kindObject:
+		default:
+			return fmt.Errorf("field selector %s: %s is not an object", fieldSelector, strings.Join(names[:i], tagexpr.FieldSeparator))
+		}
+		if !token.IsIdentifier(name) || name == "_" {
+			return fmt.Errorf("field selector %s: %q is not an identifier", fieldSelector, name)
+		}
+		f := s.field(name)
+		if f == nil {
+			f = &shapeField{name: name, shape: &shape{kind: kindNull, nullable: true}}
+			s.fields = append(s.fields, f)
+		}
+		if i < len(names)-1 {
+			s = f.shape
+			continue
+		}
+		if f.exprs == nil {
+			f.exprs = make(map[string]string, len(exprs))
+		}
+		for exprName, expr := range exprs {
+			f.exprs[exprName] = expr
+		}
+	}
+	return nil
+}
+
+var ifaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// typ returns the Go type of the shape, the objects are the structs with the expressions in the tags.
+func (s *shape) typ() reflect.Type {
+	var t reflect.Type
+	switch s.kind {
+	case kindNull, kindMixed:
+		return ifaceType
+	case kindBool:
+		t = reflect.TypeOf(false)
+	case kindNumber:
+		t = reflect.TypeOf(float64(0))
+	case kindString:
+		t = reflect.TypeOf("")
+	case kindArray:
+		t = ifaceType
+		if s.elem != nil {
+			t = s.elem.typ()
+		}
+		t = reflect.SliceOf(t)
+	case kindObject:
+		fields := make([]reflect.StructField, len(s.fields))
+		for i, f := range s.fields {
+			fields[i] = reflect.StructField{Name: f.name, Type: f.shape.typ(), Tag: f.tag()}
+			if !token.IsExported(f.name) {
+				fields[i].PkgPath = "main"
+			}
+		}
+		t = reflect.StructOf(fields)
+	}
+	if s.nullable {
+		t = reflect.PtrTo(t)
+	}
+	return t
+}
+
+func (f *shapeField) tag() reflect.StructTag {
+	if len(f.exprs) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(f.exprs))
+	for name := range f.exprs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	a := make([]string, len(names))
+	for i, name := range names {
+		a[i] = name + ":" + f.exprs[name]
+	}
+	return reflect.StructTag(tagName + ":" + strconv.Quote(strings.Join(a, ";")))
+}
+
+// build returns the pointer to the struct built from the JSON object.
+func (d *document) build() (ptr interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("can not build the struct: %v", p)
+		}
+	}()
+	t := d.typ()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	fill(v.Elem(), d.root, &shape{kind: kindObject, fields: d.fields})
+	return v.Interface(), nil
+}
+
+func fill(v reflect.Value, n *node, s *shape) {
+	if n == nil || n.kind == kindNull {
+		return
+	}
+	v = settable(v)
+	if v.Kind() == reflect.Ptr {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	switch s.kind {
+	case kindNull, kindMixed:
+		v.Set(reflect.ValueOf(n.raw()))
+	case kindBool, kindNumber, kindString:
+		v.Set(reflect.ValueOf(n.scalar))
+	case kindObject:
+		for i, f := range s.fields {
+			fill(v.Field(i), n.get(f.name), f.shape)
+		}
+	case kindArray:
+		a := reflect.MakeSlice(v.Type(), len(n.values), len(n.values))
+		for i, e := range n.values {
+			if s.elem != nil {
+				fill(a.Index(i), e, s.elem)
+			}
+		}
+		v.Set(a)
+	}
+}
+
+// settable returns the settable value of the addressable value, including the unexported fields.
+func settable(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
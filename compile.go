@@ -0,0 +1,142 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CompiledExpr the expression compiled without struct tags,
+// which can be evaluated against the structs of any type.
+// NOTE:
+//  It is safe for concurrent use by multiple goroutines.
+type CompiledExpr struct {
+	*Expr
+	source string
+	fields []string // the field selectors read by the expression
+	// bindings the checked struct types, *structVM -> error
+	bindings sync.Map
+}
+
+// compileVM the interpreter of the struct types evaluated by the compiled expressions,
+// the struct tags are ignored.
+var compileVM = New()
+
+// Compile parses the expression that is evaluated against the structs,
+// e.g. Compile("(Age)$ >= 18 && (Country)$ == 'DE'").
+// NOTE:
+//  The field selectors are relative to the struct, such as (Addr.City)$;
+//  The current field selector $ is not available, since the expression is not on a field.
+func Compile(expr string) (*CompiledExpr, error) {
+	p, err := parseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := compiledFields(p, expr)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledExpr{Expr: p, source: expr, fields: fields}, nil
+}
+
+// compiledFields returns the field selectors read by the expression,
+// the current field selector $ is not allowed.
+func compiledFields(p *Expr, source string) (fields []string, err error) {
+	walkExprNode(p.expr, func(e ExprNode) bool {
+		se, ok := e.(*selectorExprNode)
+		if !ok {
+			return true
+		}
+		if se.field == "" {
+			err = fmt.Errorf("syntax error: %q the current field $ is not available, use (Field)$", source)
+			return false
+		}
+		for _, field := range fields {
+			if field == se.field {
+				return true
+			}
+		}
+		fields = append(fields, se.field)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// MustCompile is similar to Compile, but panic when error.
+func MustCompile(expr string) *CompiledExpr {
+	c, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Source returns the source text of the expression.
+func (c *CompiledExpr) Source() string {
+	return c.source
+}
+
+// Eval evaluates the expression against the struct.
+// NOTE:
+//  The @structPtrOrReflectValue is the same as VM.Run;
+//  The field selectors are checked once per struct type,
+//  if some of them do not exist, the error wraps ErrFieldSelector.
+func (c *CompiledExpr) Eval(structPtrOrReflectValue interface{}) (Value, error) {
+	te, err := compileVM.Run(structPtrOrReflectValue)
+	if err != nil {
+		return Value{}, err
+	}
+	defer te.Release()
+	if err = c.bind(te.s); err != nil {
+		return Value{}, err
+	}
+	return Value{v: c.run("", te)}, nil
+}
+
+// Explain evaluates the expression against the struct and returns the trace tree, see Eval.
+func (c *CompiledExpr) Explain(structPtrOrReflectValue interface{}) (*ExprTrace, error) {
+	te, err := compileVM.Run(structPtrOrReflectValue)
+	if err != nil {
+		return nil, err
+	}
+	defer te.Release()
+	if err = c.bind(te.s); err != nil {
+		return nil, err
+	}
+	return c.explain("", te), nil
+}
+
+// bind checks that the field selectors of the expression resolve to the fields of the struct type.
+func (c *CompiledExpr) bind(s *structVM) error {
+	if err, ok := c.bindings.Load(s); ok {
+		if err == nil {
+			return nil
+		}
+		return err.(error)
+	}
+	var err error
+	for _, field := range c.fields {
+		if _, ok := s.fields[field]; !ok {
+			err = fmt.Errorf("%w: %s.%s in %q", ErrFieldSelector, s.name, field, c.source)
+			break
+		}
+	}
+	c.bindings.Store(s, err)
+	return err
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytedance/go-tagexpr/v2"
+)
+
+type compileAddr struct {
+	City string
+}
+
+type compileUser struct {
+	Age     int    `vd:"$>100"`
+	Country string
+	Tags    []string
+	Addr    *compileAddr
+}
+
+type compileOrder struct {
+	Age float32
+}
+
+func TestCompile(t *testing.T) {
+	e, err := tagexpr.Compile("(Age)$ >= 18 && (Country)$ == 'DE'")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "(Age)$ >= 18 && (Country)$ == 'DE'", e.Source())
+	assert.Equal(t, "(Age)$ >= 18 && (Country)$ == 'DE'", e.String())
+
+	v, err := e.Eval(&compileUser{Age: 20, Country: "DE"})
+	assert.NoError(t, err)
+	assert.Equal(t, true, v.Interface())
+	v, err = e.Eval(reflect.ValueOf(&compileUser{Age: 17, Country: "DE"}))
+	assert.NoError(t, err)
+	assert.False(t, v.Bool())
+
+	// the struct tags are ignored, and the nil parents are nil
+	e = tagexpr.MustCompile("len((Tags)$) + len((Addr.City)$)")
+	v, err = e.Eval(&compileUser{Tags: []string{"a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, v.Float())
+	v, err = e.Eval(&compileUser{Tags: []string{"a"}, Addr: &compileAddr{City: "SH"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, v.Float())
+
+	// the selectors are checked per struct type
+	e = tagexpr.MustCompile("(Age)$ > 1 && (Country)$ != ''")
+	for i := 0; i < 2; i++ {
+		_, err = e.Eval(&compileOrder{Age: 2})
+		assert.True(t, errors.Is(err, tagexpr.ErrFieldSelector))
+		assert.EqualError(t, err, `field selector does not exist: tagexpr_test.compileOrder.Country in "(Age)$ > 1 && (Country)$ != ''"`)
+	}
+	_, err = e.Eval(&compileUser{Age: 2})
+	assert.NoError(t, err)
+
+	trace, err := tagexpr.MustCompile("(Age)$ >= 18").Explain(&compileUser{Age: 17})
+	assert.NoError(t, err)
+	assert.Equal(t, "(Age)$ >= 18 => false\n  (Age)$ => 17\n  18 => 18\n", trace.String())
+
+	_, err = tagexpr.Compile("$ > 1")
+	assert.EqualError(t, err, `syntax error: "$ > 1" the current field $ is not available, use (Field)$`)
+	_, err = tagexpr.Compile("((")
+	assert.Error(t, err)
+	_, err = e.Eval(nil)
+	assert.Error(t, err)
+}
+
+func TestCompileConcurrent(t *testing.T) {
+	e := tagexpr.MustCompile("(Age)$ >= 18")
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(age int) {
+			defer wg.Done()
+			v, err := e.Eval(&compileUser{Age: age})
+			assert.NoError(t, err)
+			assert.Equal(t, age >= 18, v.Bool())
+		}(i * 3)
+	}
+	wg.Wait()
+}
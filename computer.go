@@ -0,0 +1,286 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCalcTagName the default struct tag name of the computed fields
+const DefaultCalcTagName = "calc"
+
+var (
+	// ErrCalcCycle the computed fields depend on each other
+	ErrCalcCycle = errors.New("computed fields have a dependency cycle")
+	// ErrCalcAssign the expression result can not be assigned to the computed field
+	ErrCalcAssign = errors.New("can not assign the computed value")
+)
+
+// Computer evaluates the expressions of the computed fields and writes the results back into the fields,
+// e.g. `calc:"sum((Lines)$, 'Amount')"` or `calc:"lower((Name)$)"`.
+// NOTE:
+//  Only the default expression of each field is computed, the named expressions are ignored;
+//  The fields of a struct are computed in the order of the dependencies between their expressions,
+//  and the elements of the slice, array, map and interface fields are computed before their parent;
+//  It is safe for concurrent use by multiple goroutines, as long as the same struct is not computed at the same time.
+type Computer struct {
+	vm    *VM
+	plans sync.Map // *structVM -> *calcPlan
+}
+
+// calcPlan the computing order of the fields of a struct type
+type calcPlan struct {
+	order []string
+	err   error
+}
+
+// NewComputer creates a computer of the computed fields.
+// NOTE:
+//  If tagName is not specified, DefaultCalcTagName is used;
+//  If more tag names are specified, the tags are merged by expression name, see New.
+func NewComputer(tagName ...string) *Computer {
+	if len(tagName) == 0 {
+		tagName = []string{DefaultCalcTagName}
+	}
+	return &Computer{vm: New(tagName...)}
+}
+
+// VM returns the struct tag expression interpreter.
+func (c *Computer) VM() *VM {
+	return c.vm
+}
+
+// calcGroup the handlers of the computed fields of a struct instance
+type calcGroup struct {
+	te       *TagExpr
+	handlers map[string]*ExprHandler
+}
+
+// Compute evaluates the expressions of the computed fields and assigns the results to the fields.
+// NOTE:
+//  The structPtrOrReflectValue must be a struct pointer or an addressable reflect.Value;
+//  The expression results are converted to the field types, e.g. float64 to int, uint or string,
+//  the nil result sets the zero value, and the nil pointer field is allocated;
+//  The fields of the nil nested struct pointers are skipped;
+//  If the fields have a dependency cycle, return the error wrapping ErrCalcCycle;
+//  If a result can not be converted, return the error wrapping ErrCalcAssign, the previous fields have been assigned.
+func (c *Computer) Compute(structPtrOrReflectValue interface{}) error {
+	v, isReflectValue := structPtrOrReflectValue.(reflect.Value)
+	if !isReflectValue {
+		v = reflect.ValueOf(structPtrOrReflectValue)
+		if v.Kind() != reflect.Ptr {
+			return fmt.Errorf("tagexpr: Compute requires a struct pointer, got %T", structPtrOrReflectValue)
+		}
+	}
+	te, err := c.vm.Run(v)
+	if err != nil {
+		return err
+	}
+	defer te.Release()
+
+	var groups []*calcGroup
+	err = te.Range(func(eh *ExprHandler) error {
+		es := eh.StringSelector()
+		if strings.Contains(es, ExprNameSeparator) {
+			return nil
+		}
+		t := eh.TagExpr()
+		if n := len(groups); n == 0 || groups[n-1].te != t {
+			groups = append(groups, &calcGroup{te: t, handlers: make(map[string]*ExprHandler)})
+		}
+		groups[len(groups)-1].handlers[es] = eh
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// a struct is ranged before its elements, so compute in the reverse order
+	for i := len(groups) - 1; i >= 0; i-- {
+		g := groups[i]
+		plan := c.plan(g.te.s)
+		if plan.err != nil {
+			return plan.err
+		}
+		for _, es := range plan.order {
+			eh, ok := g.handlers[es]
+			if !ok {
+				continue
+			}
+			fh, ok := g.te.Field(es)
+			if !ok {
+				continue
+			}
+			fv := fh.Value(false)
+			if !fv.IsValid() || !fv.CanSet() {
+				continue
+			}
+			if err = assignCalc(fv, eh.Eval()); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrCalcAssign, eh.Path(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// plan returns the computing order of the struct type, which is built once and cached.
+func (c *Computer) plan(s *structVM) *calcPlan {
+	if p, ok := c.plans.Load(s); ok {
+		return p.(*calcPlan)
+	}
+	p, _ := c.plans.LoadOrStore(s, s.buildCalcPlan())
+	return p.(*calcPlan)
+}
+
+// buildCalcPlan sorts the computed fields topologically, in the declaration order if independent.
+func (s *structVM) buildCalcPlan() *calcPlan {
+	var fields []string
+	for _, es := range s.exprSelectorList {
+		if !strings.Contains(es, ExprNameSeparator) {
+			fields = append(fields, es)
+		}
+	}
+	deps := make(map[string][]string, len(fields))
+	for _, f := range fields {
+		for _, g := range fields {
+			for _, dep := range s.exprDeps[f] {
+				if isFieldPrefix(g, dep) || isFieldPrefix(dep, g) {
+					deps[f] = append(deps[f], g)
+					break
+				}
+			}
+		}
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	p := &calcPlan{order: make([]string, 0, len(fields))}
+	state := make(map[string]int, len(fields))
+	var stack []string
+	var visit func(f string) bool
+	visit = func(f string) bool {
+		switch state[f] {
+		case visited:
+			return true
+		case visiting:
+			i := len(stack) - 1
+			for stack[i] != f {
+				i--
+			}
+			cycle := append(append([]string(nil), stack[i:]...), f)
+			p.err = fmt.Errorf("%w: %s: %s", ErrCalcCycle, s.name, strings.Join(cycle, " -> "))
+			return false
+		}
+		state[f] = visiting
+		stack = append(stack, f)
+		for _, g := range deps[f] {
+			if !visit(g) {
+				return false
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[f] = visited
+		p.order = append(p.order, f)
+		return true
+	}
+	for _, f := range fields {
+		if !visit(f) {
+			p.order = nil
+			break
+		}
+	}
+	return p
+}
+
+// assignCalc converts the expression result to the type of the field and assigns it.
+func assignCalc(v reflect.Value, r interface{}) error {
+	if r == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assignCalc(v.Elem(), r)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, ok := toFloat64(r, true)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%v is not a number", r)
+		}
+		i := int64(f)
+		if float64(i) != math.Trunc(f) || v.OverflowInt(i) {
+			return fmt.Errorf("%v overflows %s", r, v.Type())
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		f, ok := toFloat64(r, true)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%v is not a number", r)
+		}
+		if f < 0 {
+			return fmt.Errorf("%v overflows %s", r, v.Type())
+		}
+		u := uint64(f)
+		if float64(u) != math.Trunc(f) || v.OverflowUint(u) {
+			return fmt.Errorf("%v overflows %s", r, v.Type())
+		}
+		v.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat64(r, true)
+		if !ok {
+			return fmt.Errorf("%v is not a number", r)
+		}
+		if v.OverflowFloat(f) {
+			return fmt.Errorf("%v overflows %s", r, v.Type())
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.String:
+		switch x := r.(type) {
+		case float64:
+			v.SetString(strconv.FormatFloat(x, 'f', -1, 64))
+		case bool:
+			v.SetString(strconv.FormatBool(x))
+		default:
+			s, _ := toString(r, true)
+			v.SetString(s)
+		}
+		return nil
+	case reflect.Bool:
+		v.SetBool(FakeBool(r))
+		return nil
+	}
+	rv := reflect.ValueOf(r)
+	switch {
+	case rv.Type().AssignableTo(v.Type()):
+		v.Set(rv)
+	case rv.Type().ConvertibleTo(v.Type()):
+		v.Set(rv.Convert(v.Type()))
+	default:
+		return fmt.Errorf("%T is not assignable to %s", r, v.Type())
+	}
+	return nil
+}
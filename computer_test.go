@@ -0,0 +1,107 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytedance/go-tagexpr/v2"
+)
+
+type calcLine struct {
+	Price  float64
+	Qty    int
+	Amount float64 `calc:"(Price)$ * (Qty)$"`
+}
+
+type calcSummary struct {
+	Percent int
+	Rate    float64 `calc:"(Percent)$ / 100"`
+}
+
+type calcOrder struct {
+	// declared before the fields it depends on
+	Label   string `calc:"sprintf('%s: %v', (Slug)$, (Total)$)"`
+	Total   int    `calc:"sum((Lines)$, 'Amount')"`
+	Slug    string `calc:"lower((Name)$)"`
+	Name    string `calc:"@check:len($) > 0"`
+	Lines   []*calcLine
+	Summary *calcSummary
+	Rate    *float64 `calc:"(Summary.Rate)$"`
+	Units   uint8    `calc:"sum((Lines)$, 'Qty')"`
+	Text    string   `calc:"(Units)$"`
+	Big     bool     `calc:"(Total)$ > 10"`
+}
+
+type calcCycle struct {
+	A int `calc:"(C)$ + 1"`
+	B int `calc:"(A)$ + 1"`
+	C int `calc:"(B)$ + 1"`
+}
+
+func TestComputer(t *testing.T) {
+	c := tagexpr.NewComputer()
+	order := &calcOrder{
+		Name:    "Shop-A",
+		Lines:   []*calcLine{{Price: 2.5, Qty: 2}, nil, {Price: 3, Qty: 3}},
+		Summary: &calcSummary{Percent: 25},
+		Text:    "x",
+	}
+	assert.NoError(t, c.Compute(order))
+	assert.Equal(t, 5.0, order.Lines[0].Amount)
+	assert.Equal(t, 9.0, order.Lines[2].Amount)
+	assert.Equal(t, 14, order.Total)
+	assert.Equal(t, "shop-a", order.Slug)
+	assert.Equal(t, "shop-a: 14", order.Label)
+	assert.Equal(t, 0.25, order.Summary.Rate)
+	if assert.NotNil(t, order.Rate) {
+		assert.Equal(t, 0.25, *order.Rate)
+	}
+	assert.Equal(t, uint8(5), order.Units)
+	assert.Equal(t, "5", order.Text)
+	assert.True(t, order.Big)
+
+	// the nil nested struct is skipped, and the nil result sets the zero value
+	order = &calcOrder{Name: "B", Rate: new(float64)}
+	assert.NoError(t, c.Compute(reflect.ValueOf(order)))
+	assert.Equal(t, 0, order.Total)
+	assert.Equal(t, "b: 0", order.Label)
+	assert.Nil(t, order.Summary)
+	assert.Nil(t, order.Rate)
+
+	err := c.Compute(&calcCycle{})
+	assert.True(t, errors.Is(err, tagexpr.ErrCalcCycle))
+	assert.EqualError(t, err, "computed fields have a dependency cycle: tagexpr_test.calcCycle: A -> C -> B -> A")
+
+	err = c.Compute(&struct {
+		N int8 `calc:"300"`
+	}{})
+	assert.True(t, errors.Is(err, tagexpr.ErrCalcAssign))
+	assert.EqualError(t, err, "can not assign the computed value: N: 300 overflows int8")
+	err = c.Compute(&struct {
+		U uint `calc:"-1"`
+	}{})
+	assert.True(t, errors.Is(err, tagexpr.ErrCalcAssign))
+	err = c.Compute(&struct {
+		N int `calc:"'x'"`
+	}{})
+	assert.True(t, errors.Is(err, tagexpr.ErrCalcAssign))
+
+	assert.EqualError(t, c.Compute(calcLine{}), "tagexpr: Compute requires a struct pointer, got tagexpr_test.calcLine")
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"sort"
+	"strings"
+)
+
+// ExprDeps returns the field selectors read by the expression.
+// NOTE:
+//  format: fieldName, fieldName.exprName, fieldName1.fieldName2.exprName1
+//  The field selectors are relative to the root struct, e.g. 'A.B@x' reading (C)$ depends on 'A.C';
+//  The selectors in range bodies, function arguments and tmpl placeholders are included;
+//  If the expression selector does not exist, return nil.
+func (t *TagExpr) ExprDeps(exprSelector string) []string {
+	return append([]string(nil), t.s.exprDeps[exprSelector]...)
+}
+
+// FieldDependents returns the selectors of the expressions that read the field.
+// NOTE:
+//  Only the exact field selector is matched, the parent or child fields are not;
+//  The expression selectors are sorted.
+func (t *TagExpr) FieldDependents(fieldSelector string) []string {
+	return append([]string(nil), t.s.fieldDependents[fieldSelector]...)
+}
+
+// RangeDependents loop through each tag expression that lives on or reads the fields.
+// When fn returns error, interrupt traversal and return the error.
+// NOTE:
+//  A field also matches its parent and child fields, e.g. 'A' matches 'A.B' and vice versa;
+//  The expressions in the elements of the matched slice, array, map and interface fields are included;
+//  If fieldSelectors is empty, fn is never called.
+func (t *TagExpr) RangeDependents(fieldSelectors []string, fn func(*ExprHandler) error) error {
+	if len(fieldSelectors) == 0 {
+		return nil
+	}
+	return t.rangeFiltered(fieldSelectors, fn)
+}
+
+// isDependent returns whether the expression lives on or reads any of the fields.
+func (s *structVM) isDependent(exprSelector string, fieldSelectors []string) bool {
+	field := exprSelector
+	if idx := strings.LastIndex(field, ExprNameSeparator); idx != -1 {
+		field = field[:idx]
+	}
+	if matchAnyField(field, fieldSelectors) {
+		return true
+	}
+	for _, dep := range s.exprDeps[exprSelector] {
+		if matchAnyField(dep, fieldSelectors) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyField returns whether the field path is, or is the parent or child of, any of the field selectors.
+// NOTE:
+//  The field path may have the element suffix, such as 'A[0]' or 'A{k}'.
+func matchAnyField(fieldPath string, fieldSelectors []string) bool {
+	for _, fs := range fieldSelectors {
+		if isFieldPrefix(fs, fieldPath) || isFieldPrefix(fieldPath, fs) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFieldPrefix(prefix, fieldPath string) bool {
+	if !strings.HasPrefix(fieldPath, prefix) {
+		return false
+	}
+	if len(fieldPath) == len(prefix) {
+		return true
+	}
+	switch fieldPath[len(prefix)] {
+	case '.', '[', '{':
+		return true
+	}
+	return false
+}
+
+// buildDeps builds the dependency graph between the expressions and the fields.
+func (s *structVM) buildDeps() {
+	s.exprDeps = make(map[string][]string, len(s.exprSelectorList))
+	s.fieldDependents = make(map[string][]string)
+	for _, exprSelector := range s.exprSelectorList {
+		deps := s.exprs[exprSelector].fieldDeps(splitFieldSelector(exprSelector))
+		s.exprDeps[exprSelector] = deps
+		for _, fieldSelector := range deps {
+			s.fieldDependents[fieldSelector] = append(s.fieldDependents[fieldSelector], exprSelector)
+		}
+	}
+	for _, exprSelectors := range s.fieldDependents {
+		sort.Strings(exprSelectors)
+	}
+}
+
+// fieldDeps returns the distinct field selectors referenced by the expression,
+// the dir is the field selector prefix of the struct that the expression belongs to.
+func (p *Expr) fieldDeps(dir, currField string) []string {
+	var deps []string
+	walkExprNode(p.expr, func(e ExprNode) bool {
+		se, ok := e.(*selectorExprNode)
+		if !ok {
+			return true
+		}
+		field := se.field
+		if field == "" {
+			field = currField
+		}
+		if dir != "" {
+			field = dir + FieldSeparator + field
+		}
+		for _, dep := range deps {
+			if dep == field {
+				return true
+			}
+		}
+		deps = append(deps, field)
+		return true
+	})
+	return deps
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeps(t *testing.T) {
+	type F struct {
+		G int
+		H []string
+	}
+	type C struct {
+		D int `te:"$>(E)$"`
+		E int
+	}
+	type T struct {
+		A int `te:"$>0;x:range((B)$, #v>(F.G)$ && sprintf('%v', (A)$)!='');y:tmpl('{F.G} {$}')"`
+		B []int
+		C C `te:"len((F.H)$[(A)$])>0"`
+		F *F
+		N int `te:"1"`
+	}
+	te := New("te").MustRun(new(T))
+
+	assert.Equal(t, []string{"A"}, te.ExprDeps("A"))
+	assert.Equal(t, []string{"B", "F.G", "A"}, te.ExprDeps("A@x"))
+	assert.Equal(t, []string{"F.G", "A"}, te.ExprDeps("A@y"))
+	assert.Equal(t, []string{"F.H", "A"}, te.ExprDeps("C"))
+	assert.Equal(t, []string{"C.D", "C.E"}, te.ExprDeps("C.D"))
+	assert.Empty(t, te.ExprDeps("N"))
+	assert.Nil(t, te.ExprDeps("Z"))
+
+	assert.Equal(t, []string{"A", "A@x", "A@y", "C"}, te.FieldDependents("A"))
+	assert.Equal(t, []string{"A@x", "A@y"}, te.FieldDependents("F.G"))
+	assert.Equal(t, []string{"C.D"}, te.FieldDependents("C.E"))
+	assert.Nil(t, te.FieldDependents("F"))
+	assert.Nil(t, te.FieldDependents("N"))
+
+	var selectors []string
+	assert.NoError(t, te.RangeDependents([]string{"F"}, func(eh *ExprHandler) error {
+		selectors = append(selectors, eh.StringSelector())
+		return nil
+	}))
+	assert.ElementsMatch(t, []string{"A@x", "A@y", "C"}, selectors)
+	assert.NoError(t, te.RangeDependents(nil, func(eh *ExprHandler) error {
+		t.Fatal("unexpected call")
+		return nil
+	}))
+
+	sub, err := te.checkout("C")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"D", "E"}, sub.ExprDeps("D"))
+}
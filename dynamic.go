@@ -0,0 +1,127 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// dynamicValue the schemaless value that the field selectors resolve through
+type dynamicValue interface {
+	getValue(fieldSelector string, subFields []interface{}) interface{}
+}
+
+// EvalMap evaluates the expression against the schemaless value,
+// such as the map[string]interface{} and []interface{} trees.
+// NOTE:
+//  The field selectors resolve through the map keys and the slice indexes, e.g. (a.b)$ is m["a"]["b"], (a.0)$ is m["a"][0];
+//  currField is the field selector of '$', empty means the whole value;
+//  The numbers and json.Number are float64, the missing fields are nil.
+func (p *Expr) EvalMap(m interface{}, currField string) Value {
+	return Value{v: p.run(currField, &TagExpr{dynamic: mapValue{root: m}})}
+}
+
+// EvalJSON evaluates the expression against the JSON document, see EvalMap.
+// NOTE:
+//  The field selectors are the gjson paths, only the fields read by the expression are parsed;
+//  The JSON objects and arrays are map[string]interface{} and []interface{}.
+func (p *Expr) EvalJSON(data []byte, currField string) Value {
+	return Value{v: p.run(currField, &TagExpr{dynamic: jsonValue{data: data}})}
+}
+
+type mapValue struct {
+	root interface{}
+}
+
+func (m mapValue) getValue(fieldSelector string, subFields []interface{}) interface{} {
+	v := reflect.ValueOf(m.root)
+	if fieldSelector != "" {
+		for _, name := range strings.Split(fieldSelector, FieldSeparator) {
+			v = dynamicField(v, name)
+			if !v.IsValid() {
+				return nil
+			}
+		}
+	}
+	raw := indexReflectValue(v, subFields)
+	elem := raw
+	for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+		elem = elem.Elem()
+	}
+	if elem.IsValid() && elem.Type() == jsonNumberType {
+		f, err := strconv.ParseFloat(elem.String(), 64)
+		if err != nil {
+			return nil
+		}
+		return f
+	}
+	return anyValueGetter(raw, elem)
+}
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// dynamicField returns the field of the map, slice, array or struct by the name, or the invalid value.
+func dynamicField(v reflect.Value, name string) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		k := safeConvert(reflect.ValueOf(name), v.Type().Key())
+		if !k.IsValid() {
+			return reflect.Value{}
+		}
+		return v.MapIndex(k)
+	case reflect.Slice, reflect.Array:
+		idx, err := strconv.Atoi(name)
+		if err != nil || idx < 0 || idx >= v.Len() {
+			return reflect.Value{}
+		}
+		return v.Index(idx)
+	case reflect.Struct:
+		return v.FieldByName(name)
+	}
+	return reflect.Value{}
+}
+
+type jsonValue struct {
+	data []byte
+}
+
+func (j jsonValue) getValue(fieldSelector string, subFields []interface{}) interface{} {
+	var r gjson.Result
+	if fieldSelector == "" {
+		r = gjson.ParseBytes(j.data)
+	} else {
+		r = gjson.GetBytes(j.data, fieldSelector)
+	}
+	var v interface{}
+	switch r.Type {
+	case gjson.Number:
+		v = r.Float()
+	case gjson.String:
+		v = r.Str
+	case gjson.True, gjson.False:
+		v = r.Bool()
+	case gjson.JSON:
+		v = r.Value()
+	}
+	return indexValue(v, subFields)
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytedance/go-tagexpr/v2"
+)
+
+const dynamicDoc = `{
+	"name": "henry",
+	"age": 18,
+	"ok": true,
+	"note": null,
+	"addr": {"city": "SH", "zip": "200000"},
+	"tags": ["a", "b"],
+	"items": [{"price": 3}, {"price": -1}],
+	"first-name": "h"
+}`
+
+func TestEvalMapAndJSON(t *testing.T) {
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader([]byte(dynamicDoc)))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	var cases = []struct {
+		expr      string
+		currField string
+		want      interface{}
+	}{
+		{"len((name)$)>1 && (age)$>=18", "", true},
+		{"(addr.city)$", "", "SH"},
+		{"$=='SH'", "addr.city", true},
+		{"len($)", "tags", 2.0},
+		{"$[1]", "tags", "b"},
+		{"(tags.0)$+(tags)$[1]", "", "ab"},
+		{"(items.1.price)$", "", -1.0},
+		{"(items)$[0]['price']", "", 3.0},
+		{"range((tags)$, #v=='a')", "", []interface{}{true, false}},
+		{"$['first-name']", "", "h"},
+		{"(note)$==nil && (nope)$==nil && (addr.nope.x)$==nil", "", true},
+		{"!(ok)$", "", false},
+		{"len($)", "", 8.0},
+		{"sprintf('%s@%v', (name)$, (addr.zip)$)", "", "henry@200000"},
+		{"(name)$ =~ '^h'", "", true},
+	}
+	for _, c := range cases {
+		expr, err := tagexpr.Parse(c.expr)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		assert.Equal(t, c.want, expr.EvalMap(m, c.currField).Interface(), "map: %s", c.expr)
+		assert.Equal(t, c.want, expr.EvalJSON([]byte(dynamicDoc), c.currField).Interface(), "json: %s", c.expr)
+	}
+
+	expr, _ := tagexpr.Parse("(a.b)$ + (c.0)$")
+	got := expr.EvalMap(map[string]interface{}{
+		"a": map[string]int{"b": 1},
+		"c": []int64{2},
+	}, "")
+	assert.Equal(t, 3.0, got.Interface())
+	assert.True(t, expr.EvalJSON([]byte(`[1]`), "").IsNil())
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ExprTrace the evaluation trace tree of the expression
+type ExprTrace struct {
+	// Source the normalized source text of the expression node
+	Source string
+	// Value the evaluation result of the expression node
+	Value interface{}
+	// Operands the traces of the evaluated operands or arguments,
+	// the operands skipped by the short-circuit of && and || are not included,
+	// for range(), the first is the iterated object and the others are the elements
+	Operands []*ExprTrace
+	node     ExprNode
+}
+
+// explain evaluates the expression and returns the trace tree.
+// NOTE:
+//  The sub-expressions may be evaluated more than once.
+func (p *Expr) explain(field string, tagExpr *TagExpr) *ExprTrace {
+	return explainExprNode(context.Background(), p.expr, field, tagExpr)
+}
+
+func explainExprNode(ctx context.Context, e ExprNode, currField string, tagExpr *TagExpr) *ExprTrace {
+	if g, ok := e.(*groupExprNode); ok && !g.paren {
+		if g.rightOperand == nil {
+			return &ExprTrace{node: e}
+		}
+		return explainExprNode(ctx, g.rightOperand, currField, tagExpr)
+	}
+	t := &ExprTrace{Source: formatExprNode(e), node: e}
+	switch n := e.(type) {
+	case *andExprNode, *orExprNode:
+		_, isAnd := n.(*andExprNode)
+		left := explainExprNode(ctx, e.LeftOperand(), currField, tagExpr)
+		t.Operands = append(t.Operands, left)
+		if FakeBool(left.Value) == isAnd {
+			t.Operands = append(t.Operands, explainExprNode(ctx, e.RightOperand(), currField, tagExpr))
+		}
+	case *rangeFuncExprNode:
+		obj := explainExprNode(ctx, n.object, currField, tagExpr)
+		t.Operands = append(t.Operands, obj)
+		n.each(ctx, obj.Value, func(_ int, elemCtx context.Context) {
+			t.Operands = append(t.Operands, explainExprNode(elemCtx, n.elemExprNode, currField, tagExpr))
+		})
+	default:
+		for _, sub := range subExprNodes(e) {
+			t.Operands = append(t.Operands, explainExprNode(ctx, sub, currField, tagExpr))
+		}
+	}
+	t.Value = e.Run(ctx, currField, tagExpr)
+	return t
+}
+
+// FailedClause returns the innermost sub-clause that makes the result false.
+// NOTE:
+//  It descends through the && operands and the parentheses;
+//  If the result is true, return nil.
+func (t *ExprTrace) FailedClause() *ExprTrace {
+	if t == nil || FakeBool(t.Value) {
+		return nil
+	}
+	for {
+		var next *ExprTrace
+		switch n := t.node.(type) {
+		case *andExprNode:
+			for _, o := range t.Operands {
+				if !FakeBool(o.Value) {
+					next = o
+					break
+				}
+			}
+		case *groupExprNode:
+			if n.boolOpposite == nil && len(t.Operands) == 1 {
+				next = t.Operands[0]
+			}
+		}
+		if next == nil {
+			return t
+		}
+		t = next
+	}
+}
+
+// String returns the indented text of the trace tree.
+func (t *ExprTrace) String() string {
+	var b strings.Builder
+	t.write(&b, 0)
+	return b.String()
+}
+
+func (t *ExprTrace) write(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(t.Source)
+	b.WriteString(" => ")
+	switch v := t.Value.(type) {
+	case nil, bool, float64, string:
+		writeLiteral(b, v)
+	default:
+		fmt.Fprintf(b, "%v", v)
+	}
+	b.WriteByte('\n')
+	for _, o := range t.Operands {
+		o.write(b, depth+1)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExprString(t *testing.T) {
+	var cases = []struct {
+		expr, src string
+	}{
+		{expr: "1+2*3", src: "1 + 2 * 3"},
+		{expr: "(1+2)*3", src: "(1 + 2) * 3"},
+		{expr: "!($>0)&&-(A)$<=10", src: "!($ > 0) && -(A)$ <= 10"},
+		{expr: "$['a'][0]=='x'||nil==nil", src: "$['a'][0] == 'x' || nil == nil"},
+		{expr: "len($)>0&&!regexp('\\d')", src: "len($) > 0 && !regexp('\\d', $)"},
+		{expr: "range($,#v*##)", src: "range($, #v * ##)"},
+		{expr: "sprintf('%v:%v',#k,'\\'')", src: "sprintf('%v:%v', #k, '\\'')"},
+		{expr: "tmpl('{$}')", src: "tmpl('{$}')"},
+		{expr: "$=~'a'&&$!~(P)$", src: "$ =~ 'a' && $ !~ (P)$"},
+		{expr: "like('a%')", src: "like('a%', $)"},
+	}
+	for _, c := range cases {
+		e, err := parseExpr(c.expr)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		assert.Equal(t, c.src, e.String(), c.expr)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	type T struct {
+		Name string `te:"($!='Alice'||(Age)$==18) && regexp('^\\w+$') && len($)>3"`
+		Age  int    `te:"range((Tags)$, #v!='') && $>0"`
+		Tags []string
+	}
+	vm := New("te")
+	te := vm.MustRun(&T{Name: "Alice", Age: 20, Tags: []string{"x", ""}})
+
+	tr := te.Explain("Name")
+	assert.Equal(t, false, tr.Value)
+	assert.Equal(t, "($ != 'Alice' || (Age)$ == 18) && regexp('^\\w+$', $) && len($) > 3", tr.Source)
+	failed := tr.FailedClause()
+	assert.Equal(t, "$ != 'Alice' || (Age)$ == 18", failed.Source)
+	assert.Len(t, failed.Operands, 2)
+	assert.Equal(t, 20.0, failed.Operands[1].Operands[0].Value)
+	t.Log(tr)
+
+	tr = te.Explain("Age")
+	assert.Equal(t, false, tr.Value)
+	failed = tr.FailedClause()
+	assert.Equal(t, "range((Tags)$, #v != '')", failed.Source)
+	assert.Len(t, failed.Operands, 3)
+	assert.Equal(t, false, failed.Operands[2].Value)
+
+	// short-circuit
+	te = vm.MustRun(&T{Name: "Bobby", Age: 20})
+	tr = te.Explain("Name")
+	assert.Equal(t, true, tr.Value)
+	assert.Nil(t, tr.FailedClause())
+	assert.Len(t, tr.Operands[0].Operands[0].Operands, 1)
+
+	assert.Nil(t, te.Explain("X"))
+	assert.NoError(t, te.Range(func(eh *ExprHandler) error {
+		assert.Equal(t, te.Eval(eh.StringSelector()), eh.Explain().Value)
+		return nil
+	}))
+}
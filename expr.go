@@ -24,6 +24,13 @@ type Expr struct {
 	expr ExprNode
 }
 
+// Parse parses the expression, reporting the syntax errors.
+// NOTE:
+//  The field selectors are not resolved, they depend on the struct that the expression is used in.
+func Parse(expr string) (*Expr, error) {
+	return parseExpr(expr)
+}
+
 // parseExpr parses the expression.
 func parseExpr(expr string) (*Expr, error) {
 	e := newGroupExprNode()
@@ -96,6 +103,10 @@ func (*Expr) parseOperator(expr *string) (e ExprNode) {
 		return newLessEqualExprNode()
 	case "!=":
 		return newNotEqualExprNode()
+	case "=~":
+		return newMatchExprNode()
+	case "!~":
+		return newNotMatchExprNode()
 	}
 	defer func() {
 		if e != nil {
@@ -172,17 +183,86 @@ func (p *Expr) parseExprNode(expr *string, e ExprNode) (ExprNode, error) {
 }
 
 func (p *Expr) checkSyntax() error {
+	return checkRangeScope(p.expr, false)
+}
 
+// checkRangeScope checks that the range variables of the tmpl placeholders are used inside range().
+func checkRangeScope(e ExprNode, inRange bool) error {
+	switch t := e.(type) {
+	case nil:
+		return nil
+	case *rangeFuncExprNode:
+		if err := checkRangeScope(t.object, inRange); err != nil {
+			return err
+		}
+		return checkRangeScope(t.elemExprNode, true)
+	case *tmplExprNode:
+		if !inRange {
+			for i, operand := range t.placeholders {
+				if _, ok := operand.(*rangeKvExprNode); ok {
+					return fmt.Errorf("syntax error: tmpl placeholder {%s} is used outside range()", t.names[i])
+				}
+			}
+		}
+	}
+	for _, sub := range subExprNodes(e) {
+		if err := checkRangeScope(sub, inRange); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// subExprNodes returns the direct sub-expressions of the node.
+func subExprNodes(e ExprNode) []ExprNode {
+	var a []ExprNode
+	if l := e.LeftOperand(); l != nil {
+		a = append(a, l)
+	}
+	if r := e.RightOperand(); r != nil {
+		a = append(a, r)
+	}
+	switch t := e.(type) {
+	case *funcExprNode:
+		a = append(a, t.args...)
+	case *rangeFuncExprNode:
+		a = append(a, t.object, t.elemExprNode)
+	case *selectorExprNode:
+		a = append(a, t.subExprs...)
+	case *sprintfFuncExprNode:
+		a = append(a, t.args...)
+	case *regexpFuncExprNode:
+		return []ExprNode{t.pattern, t.rightOperand}
+	case *tmplExprNode:
+		a = append(a, t.placeholders...)
+	}
+	return a
+}
+
+// walkExprNode traverses the expression tree in depth-first order.
+// When fn returns false, interrupt traversal and return false.
+func walkExprNode(e ExprNode, fn func(ExprNode) bool) bool {
+	if e == nil {
+		return true
+	}
+	if !fn(e) {
+		return false
+	}
+	for _, sub := range subExprNodes(e) {
+		if !walkExprNode(sub, fn) {
+			return false
+		}
+	}
+	return true
+}
+
 /**
  * Priority:
  * () ! bool float64 string nil
  * * / %
  * + -
  * < <= > >=
- * == !=
+ * == != =~ !~
  * &&
  * ||
 **/
@@ -218,7 +298,7 @@ func getPriority(e ExprNode) (i int) {
 		return 5
 	case *lessExprNode, *lessEqualExprNode, *greaterExprNode, *greaterEqualExprNode: // < <= > >=
 		return 4
-	case *equalExprNode, *notEqualExprNode: // == !=
+	case *equalExprNode, *notEqualExprNode, *matchExprNode: // == != =~ !~
 		return 3
 	case *andExprNode: // &&
 		return 2
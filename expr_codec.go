@@ -0,0 +1,754 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/bytedance/go-tagexpr/v2/internal/exprtree"
+)
+
+// ExprFormatVersion the version of the serialized expression format,
+// the expressions serialized with the other versions are rejected when loading.
+const ExprFormatVersion = 1
+
+// ErrExprFormat the serialized expression is invalid or of an unsupported version
+var ErrExprFormat = errors.New("invalid serialized expression")
+
+// exprBinaryMagic the header of the binary format, followed by the version byte
+const exprBinaryMagic = "TGX"
+
+// exprNodeOps the kinds of the serialized nodes.
+// NOTE:
+//  The index is the code of the binary format, so only append to the list,
+//  the code 0 is reserved for the nil node.
+var exprNodeOps = []string{
+	"",
+	"group", "bool", "string", "number", "nil",
+	"selector", "func", "sprintf", "regexp", "range", "rangekv", "tmpl",
+	"+", "-", "*", "/", "%",
+	"==", "!=", ">", ">=", "<", "<=",
+	"&&", "||", "=~", "!~",
+}
+
+var exprNodeOpCodes = func() map[string]byte {
+	m := make(map[string]byte, len(exprNodeOps))
+	for i, op := range exprNodeOps {
+		m[op] = byte(i)
+	}
+	return m
+}()
+
+// exprOperators the constructors of the binary operator nodes by op
+var exprOperators = map[string]func() ExprNode{
+	"+":  newAdditionExprNode,
+	"-":  newSubtractionExprNode,
+	"*":  newMultiplicationExprNode,
+	"/":  newDivisionExprNode,
+	"%":  newRemainderExprNode,
+	"==": newEqualExprNode,
+	"!=": newNotEqualExprNode,
+	">":  newGreaterExprNode,
+	">=": newGreaterEqualExprNode,
+	"<":  newLessExprNode,
+	"<=": newLessEqualExprNode,
+	"&&": newAndExprNode,
+	"||": newOrExprNode,
+	"=~": newMatchExprNode,
+	"!~": newNotMatchExprNode,
+}
+
+// patternTranslators the translators of the pattern functions by name, see readPatternFuncExprNode
+var patternTranslators = map[string]func(string) (string, error){
+	"regexp": nil,
+	"glob":   globToRegexp,
+	"like":   likeToRegexp,
+	"ilike":  ilikeToRegexp,
+}
+
+// exprDocument the serialized expression
+type exprDocument struct {
+	Version int           `json:"version"`
+	Source  string        `json:"source,omitempty"`
+	Expr    *exprNodeData `json:"expr"`
+}
+
+// exprNodeData the serialized expression node, see exprtree.Node
+type exprNodeData = exprtree.Node
+
+// MarshalJSON serializes the parsed expression tree to JSON, mainly for debugging.
+// NOTE:
+//  The result is loaded by UnmarshalJSON without parsing the expression again.
+func (p *Expr) MarshalJSON() ([]byte, error) {
+	return p.marshalJSON(p.String())
+}
+
+// UnmarshalJSON loads the expression tree serialized by MarshalJSON.
+// NOTE:
+//  The functions used in the expression must be registered, otherwise the error wraps ErrUnknownFunc;
+//  If the data is invalid or of another format version, the error wraps ErrExprFormat;
+//  The tree is checked node by node, e.g. the missing operands and the wrong number of function arguments are invalid.
+func (p *Expr) UnmarshalJSON(data []byte) error {
+	_, err := p.unmarshalJSON(data)
+	return err
+}
+
+// MarshalBinary serializes the parsed expression tree to the compact binary format.
+// NOTE:
+//  The result is loaded by UnmarshalBinary without parsing the expression again.
+func (p *Expr) MarshalBinary() ([]byte, error) {
+	return p.marshalBinary("")
+}
+
+// UnmarshalBinary loads the expression tree serialized by MarshalBinary, see UnmarshalJSON.
+func (p *Expr) UnmarshalBinary(data []byte) error {
+	_, err := p.unmarshalBinary(data)
+	return err
+}
+
+// MarshalJSON serializes the compiled expression with its source text, see Expr.MarshalJSON.
+func (c *CompiledExpr) MarshalJSON() ([]byte, error) {
+	return c.Expr.marshalJSON(c.source)
+}
+
+// UnmarshalJSON loads the compiled expression serialized by MarshalJSON, see Expr.UnmarshalJSON.
+func (c *CompiledExpr) UnmarshalJSON(data []byte) error {
+	p := new(Expr)
+	source, err := p.unmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	return c.load(p, source)
+}
+
+// MarshalBinary serializes the compiled expression with its source text, see Expr.MarshalBinary.
+func (c *CompiledExpr) MarshalBinary() ([]byte, error) {
+	return c.Expr.marshalBinary(c.source)
+}
+
+// UnmarshalBinary loads the compiled expression serialized by MarshalBinary, see Expr.UnmarshalJSON.
+func (c *CompiledExpr) UnmarshalBinary(data []byte) error {
+	p := new(Expr)
+	source, err := p.unmarshalBinary(data)
+	if err != nil {
+		return err
+	}
+	return c.load(p, source)
+}
+
+// load resets the compiled expression to the loaded expression tree.
+func (c *CompiledExpr) load(p *Expr, source string) error {
+	if source == "" {
+		source = p.String()
+	}
+	fields, err := compiledFields(p, source)
+	if err != nil {
+		return err
+	}
+	c.Expr, c.source, c.fields = p, source, fields
+	c.bindings = sync.Map{}
+	return nil
+}
+
+func (p *Expr) marshalJSON(source string) ([]byte, error) {
+	d, err := encodeExprNode(p.expr)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&exprDocument{Version: ExprFormatVersion, Source: source, Expr: d})
+}
+
+func (p *Expr) unmarshalJSON(data []byte) (source string, err error) {
+	var doc exprDocument
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrExprFormat, err)
+	}
+	if doc.Version != ExprFormatVersion {
+		return "", fmt.Errorf("%w: unsupported version %d, want %d", ErrExprFormat, doc.Version, ExprFormatVersion)
+	}
+	return doc.Source, p.load(doc.Expr)
+}
+
+func (p *Expr) marshalBinary(source string) ([]byte, error) {
+	d, err := encodeExprNode(p.expr)
+	if err != nil {
+		return nil, err
+	}
+	w := &exprWriter{buf: make([]byte, 0, 64)}
+	w.buf = append(w.buf, exprBinaryMagic...)
+	w.buf = append(w.buf, ExprFormatVersion)
+	w.string(source)
+	w.node(d)
+	return w.buf, nil
+}
+
+func (p *Expr) unmarshalBinary(data []byte) (source string, err error) {
+	if len(data) < len(exprBinaryMagic)+1 || string(data[:len(exprBinaryMagic)]) != exprBinaryMagic {
+		return "", fmt.Errorf("%w: bad header", ErrExprFormat)
+	}
+	if v := int(data[len(exprBinaryMagic)]); v != ExprFormatVersion {
+		return "", fmt.Errorf("%w: unsupported version %d, want %d", ErrExprFormat, v, ExprFormatVersion)
+	}
+	r := &exprReader{buf: data[len(exprBinaryMagic)+1:]}
+	source = r.string()
+	d := r.node(0)
+	if r.err == nil && len(r.buf) > 0 {
+		r.fail("%d trailing bytes", len(r.buf))
+	}
+	if r.err != nil {
+		return "", r.err
+	}
+	return source, p.load(d)
+}
+
+// load sets the expression tree decoded from the serialized nodes.
+func (p *Expr) load(d *exprNodeData) error {
+	if d == nil || d.Op != "group" {
+		return fmt.Errorf("%w: the root is not a group", ErrExprFormat)
+	}
+	e, err := decodeExprNode(d)
+	if err != nil {
+		return err
+	}
+	p.expr = e
+	return p.checkSyntax()
+}
+
+func encodeExprNode(e ExprNode) (*exprNodeData, error) {
+	if e == nil {
+		return nil, nil
+	}
+	d := new(exprNodeData)
+	var args []ExprNode
+	switch t := e.(type) {
+	case *groupExprNode:
+		d.Op, d.Not, d.Neg, d.Paren = "group", t.boolOpposite, t.signOpposite, t.paren
+	case *boolExprNode:
+		d.Op, d.Value = "bool", t.val
+	case *stringExprNode:
+		d.Op, d.Value = "string", t.val
+	case *digitalExprNode:
+		d.Op, d.Value = "number", t.val
+	case *nilExprNode:
+		d.Op, d.Value = "nil", t.val
+	case *selectorExprNode:
+		d.Op, d.Field, d.Name, d.Not, d.Neg = "selector", t.field, t.name, t.boolOpposite, t.signOpposite
+		args = t.subExprs
+	case *funcExprNode:
+		d.Op, d.Name, d.Not, d.Neg = "func", t.name, t.boolOpposite, t.signOpposite
+		args = t.args
+	case *sprintfFuncExprNode:
+		d.Op, d.Name = "sprintf", t.format
+		args = t.args
+	case *regexpFuncExprNode:
+		d.Op, d.Name = "regexp", t.name
+		if t.boolOpposite {
+			d.Not = &t.boolOpposite
+		}
+		args = []ExprNode{t.pattern}
+	case *rangeFuncExprNode:
+		d.Op, d.Not, d.Neg = "range", t.boolOpposite, t.signOpposite
+		args = []ExprNode{t.object, t.elemExprNode}
+	case *rangeKvExprNode:
+		d.Op, d.Name, d.Not, d.Neg = "rangekv", string(t.ctxKey), t.boolOpposite, t.signOpposite
+	case *tmplExprNode:
+		d.Op, d.Name, d.Texts, d.Names = "tmpl", t.format, t.texts, t.names
+		args = t.placeholders
+	case *additionExprNode:
+		d.Op = "+"
+	case *subtractionExprNode:
+		d.Op = "-"
+	case *multiplicationExprNode:
+		d.Op = "*"
+	case *divisionExprNode:
+		d.Op = "/"
+	case *remainderExprNode:
+		d.Op = "%"
+	case *equalExprNode:
+		d.Op = "=="
+	case *notEqualExprNode:
+		d.Op = "!="
+	case *greaterExprNode:
+		d.Op = ">"
+	case *greaterEqualExprNode:
+		d.Op = ">="
+	case *lessExprNode:
+		d.Op = "<"
+	case *lessEqualExprNode:
+		d.Op = "<="
+	case *andExprNode:
+		d.Op = "&&"
+	case *orExprNode:
+		d.Op = "||"
+	case *matchExprNode:
+		d.Op = "=~"
+		if t.opposite {
+			d.Op = "!~"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported expression node %T", e)
+	}
+	var err error
+	if d.Left, err = encodeExprNode(e.LeftOperand()); err != nil {
+		return nil, err
+	}
+	if d.Right, err = encodeExprNode(e.RightOperand()); err != nil {
+		return nil, err
+	}
+	if len(args) > 0 {
+		d.Args = make([]*exprNodeData, len(args))
+		for i, arg := range args {
+			if d.Args[i], err = encodeExprNode(arg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return d, nil
+}
+
+func decodeExprNode(d *exprNodeData) (ExprNode, error) {
+	if d == nil {
+		return nil, nil
+	}
+	if err := checkExprNodeData(d); err != nil {
+		return nil, err
+	}
+	args := make([]ExprNode, len(d.Args))
+	for i, a := range d.Args {
+		arg, err := decodeExprNode(a)
+		if err != nil {
+			return nil, err
+		}
+		if arg == nil {
+			return nil, fmt.Errorf("%w: nil argument %d of %s", ErrExprFormat, i, d.Op)
+		}
+		args[i] = arg
+	}
+	var e ExprNode
+	switch d.Op {
+	case "group":
+		e = &groupExprNode{boolOpposite: d.Not, signOpposite: d.Neg, paren: d.Paren}
+	case "bool":
+		v, ok := d.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: bad bool value %v", ErrExprFormat, d.Value)
+		}
+		e = &boolExprNode{val: v}
+	case "string", "number", "nil":
+		switch d.Value.(type) {
+		case bool:
+		case string:
+			if d.Op != "string" {
+				return nil, fmt.Errorf("%w: bad %s value %q", ErrExprFormat, d.Op, d.Value)
+			}
+		case float64:
+			if d.Op != "number" {
+				return nil, fmt.Errorf("%w: bad %s value %v", ErrExprFormat, d.Op, d.Value)
+			}
+		case nil:
+			if d.Op != "nil" {
+				return nil, fmt.Errorf("%w: bad %s value nil", ErrExprFormat, d.Op)
+			}
+		default:
+			return nil, fmt.Errorf("%w: bad %s value %v", ErrExprFormat, d.Op, d.Value)
+		}
+		switch d.Op {
+		case "string":
+			e = &stringExprNode{val: d.Value}
+		case "number":
+			e = &digitalExprNode{val: d.Value}
+		default:
+			e = &nilExprNode{val: d.Value}
+		}
+	case "selector":
+		if d.Name != "$" && d.Name != oldSelector {
+			return nil, fmt.Errorf("%w: bad selector name %q", ErrExprFormat, d.Name)
+		}
+		e = &selectorExprNode{field: d.Field, name: d.Name, subExprs: args, boolOpposite: d.Not, signOpposite: d.Neg}
+	case "func":
+		f, ok := funcFns[d.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownFunc, d.Name)
+		}
+		if err := checkFuncArity(d.Name, args); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrExprFormat, err)
+		}
+		e = &funcExprNode{name: d.Name, args: args, fn: f.fn, boolOpposite: d.Not, signOpposite: d.Neg}
+	case "sprintf":
+		e = &sprintfFuncExprNode{format: d.Name, args: args}
+	case "regexp":
+		translate, ok := patternTranslators[d.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownFunc, d.Name)
+		}
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: %s has %d patterns, want 1", ErrExprFormat, d.Name, len(args))
+		}
+		re, err := compileLiteralPattern(args[0], translate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrExprFormat, d.Name, err)
+		}
+		e = &regexpFuncExprNode{name: d.Name, re: re, pattern: args[0], translate: translate, boolOpposite: d.Not != nil && *d.Not}
+	case "range":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%w: range has %d arguments, want 2", ErrExprFormat, len(args))
+		}
+		e = &rangeFuncExprNode{object: args[0], elemExprNode: args[1], boolOpposite: d.Not, signOpposite: d.Neg}
+	case "rangekv":
+		switch key := rangeCtxKey(d.Name); key {
+		case rangeKey, rangeValue, rangeLen:
+			e = &rangeKvExprNode{ctxKey: key, boolOpposite: d.Not, signOpposite: d.Neg}
+		default:
+			return nil, fmt.Errorf("%w: unknown range variable %q", ErrExprFormat, d.Name)
+		}
+	case "tmpl":
+		if len(d.Texts) != len(args)+1 || len(d.Names) != len(args) {
+			return nil, fmt.Errorf("%w: tmpl has %d texts and %d names for %d placeholders", ErrExprFormat, len(d.Texts), len(d.Names), len(args))
+		}
+		e = &tmplExprNode{format: d.Name, texts: d.Texts, placeholders: args, names: d.Names}
+	default:
+		newOperator, ok := exprOperators[d.Op]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown node %q", ErrExprFormat, d.Op)
+		}
+		e = newOperator()
+	}
+	for _, operand := range [2]struct {
+		d   *exprNodeData
+		set func(ExprNode)
+	}{{d.Left, e.SetLeftOperand}, {d.Right, e.SetRightOperand}} {
+		sub, err := decodeExprNode(operand.d)
+		if err != nil {
+			return nil, err
+		}
+		if sub != nil {
+			operand.set(sub)
+			sub.SetParent(e)
+		}
+	}
+	return e, nil
+}
+
+// checkExprNodeData checks the operands and the number of arguments required by the kind of the node,
+// so that the loaded tree never dereferences a missing node when running.
+// NOTE:
+//  The operators require both operands, regexp requires the right one (the matched value),
+//  group allows only the right one, and the other nodes have no operands;
+//  The literals, groups and range variables have no arguments.
+func checkExprNodeData(d *exprNodeData) error {
+	left, right := "none", "none"
+	noArgs := false
+	switch d.Op {
+	case "group":
+		right, noArgs = "optional", true
+	case "bool", "string", "number", "nil", "rangekv":
+		noArgs = true
+	case "selector", "func", "sprintf", "range", "tmpl":
+	case "regexp":
+		right = "required"
+	default:
+		if _, ok := exprOperators[d.Op]; !ok {
+			return fmt.Errorf("%w: unknown node %q", ErrExprFormat, d.Op)
+		}
+		left, right, noArgs = "required", "required", true
+	}
+	for _, operand := range [2]struct {
+		side string
+		d    *exprNodeData
+		want string
+	}{{"left", d.Left, left}, {"right", d.Right, right}} {
+		switch {
+		case operand.want == "required" && operand.d == nil:
+			return fmt.Errorf("%w: %s needs the %s operand", ErrExprFormat, d.Op, operand.side)
+		case operand.want == "none" && operand.d != nil:
+			return fmt.Errorf("%w: %s has no %s operand", ErrExprFormat, d.Op, operand.side)
+		}
+	}
+	if noArgs && len(d.Args) > 0 {
+		return fmt.Errorf("%w: %s has %d arguments, want 0", ErrExprFormat, d.Op, len(d.Args))
+	}
+	return nil
+}
+
+// the flags of the node in the binary format
+const (
+	exprFlagHasNot = 1 << iota
+	exprFlagNot
+	exprFlagHasNeg
+	exprFlagNeg
+	exprFlagParen
+	exprFlagLeft
+	exprFlagRight
+	exprFlagField
+	exprFlagName
+	exprFlagValue
+	exprFlagTmpl
+	exprFlagArgs
+)
+
+// the types of the literal values in the binary format
+const (
+	exprValueNil byte = iota
+	exprValueFalse
+	exprValueTrue
+	exprValueNumber
+	exprValueString
+)
+
+// exprWriter writes the binary format:
+// magic, version, source, node;
+// node: op, flags, [field], [name], [value], [texts, names], [left], [right], [args].
+type exprWriter struct {
+	buf []byte
+}
+
+func (w *exprWriter) uvarint(x uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf = append(w.buf, b[:binary.PutUvarint(b[:], x)]...)
+}
+
+func (w *exprWriter) string(s string) {
+	w.uvarint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *exprWriter) strings(a []string) {
+	w.uvarint(uint64(len(a)))
+	for _, s := range a {
+		w.string(s)
+	}
+}
+
+func (w *exprWriter) node(d *exprNodeData) {
+	if d == nil {
+		w.buf = append(w.buf, 0)
+		return
+	}
+	w.buf = append(w.buf, exprNodeOpCodes[d.Op])
+	var flags uint64
+	if d.Not != nil {
+		flags |= exprFlagHasNot
+		if *d.Not {
+			flags |= exprFlagNot
+		}
+	}
+	if d.Neg != nil {
+		flags |= exprFlagHasNeg
+		if *d.Neg {
+			flags |= exprFlagNeg
+		}
+	}
+	for _, f := range []struct {
+		ok   bool
+		flag uint64
+	}{
+		{d.Paren, exprFlagParen},
+		{d.Left != nil, exprFlagLeft},
+		{d.Right != nil, exprFlagRight},
+		{d.Field != "", exprFlagField},
+		{d.Name != "", exprFlagName},
+		{d.Value != nil, exprFlagValue},
+		{d.Op == "tmpl", exprFlagTmpl},
+		{len(d.Args) > 0, exprFlagArgs},
+	} {
+		if f.ok {
+			flags |= f.flag
+		}
+	}
+	w.uvarint(flags)
+	if flags&exprFlagField != 0 {
+		w.string(d.Field)
+	}
+	if flags&exprFlagName != 0 {
+		w.string(d.Name)
+	}
+	if flags&exprFlagValue != 0 {
+		switch v := d.Value.(type) {
+		case bool:
+			if v {
+				w.buf = append(w.buf, exprValueTrue)
+			} else {
+				w.buf = append(w.buf, exprValueFalse)
+			}
+		case float64:
+			w.buf = append(w.buf, exprValueNumber)
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+			w.buf = append(w.buf, b[:]...)
+		case string:
+			w.buf = append(w.buf, exprValueString)
+			w.string(v)
+		default:
+			w.buf = append(w.buf, exprValueNil)
+		}
+	}
+	if flags&exprFlagTmpl != 0 {
+		w.strings(d.Texts)
+		w.strings(d.Names)
+	}
+	if flags&exprFlagLeft != 0 {
+		w.node(d.Left)
+	}
+	if flags&exprFlagRight != 0 {
+		w.node(d.Right)
+	}
+	if flags&exprFlagArgs != 0 {
+		w.uvarint(uint64(len(d.Args)))
+		for _, a := range d.Args {
+			w.node(a)
+		}
+	}
+}
+
+// exprMaxDepth the max depth of the nodes in the binary format, guards the corrupted data
+const exprMaxDepth = 1 << 10
+
+// exprReader reads the binary format written by exprWriter,
+// after the first error, the reads return the zero values.
+type exprReader struct {
+	buf []byte
+	err error
+}
+
+func (r *exprReader) fail(format string, a ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s", ErrExprFormat, fmt.Sprintf(format, a...))
+	}
+	r.buf = nil
+}
+
+func (r *exprReader) byte() byte {
+	if len(r.buf) == 0 {
+		r.fail("unexpected end")
+		return 0
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *exprReader) uvarint() uint64 {
+	x, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail("bad varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return x
+}
+
+// count reads a length which is at most the number of the remaining bytes.
+func (r *exprReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.buf)) {
+		r.fail("length %d out of range", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *exprReader) string() string {
+	n := r.count()
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+	return s
+}
+
+func (r *exprReader) strings() []string {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+	a := make([]string, n)
+	for i := range a {
+		a[i] = r.string()
+	}
+	return a
+}
+
+func (r *exprReader) node(depth int) *exprNodeData {
+	if depth > exprMaxDepth {
+		r.fail("too deep")
+		return nil
+	}
+	code := r.byte()
+	if code == 0 || r.err != nil {
+		return nil
+	}
+	if int(code) >= len(exprNodeOps) {
+		r.fail("unknown node code %d", code)
+		return nil
+	}
+	d := &exprNodeData{Op: exprNodeOps[code]}
+	flags := r.uvarint()
+	if flags&exprFlagHasNot != 0 {
+		not := flags&exprFlagNot != 0
+		d.Not = &not
+	}
+	if flags&exprFlagHasNeg != 0 {
+		neg := flags&exprFlagNeg != 0
+		d.Neg = &neg
+	}
+	d.Paren = flags&exprFlagParen != 0
+	if flags&exprFlagField != 0 {
+		d.Field = r.string()
+	}
+	if flags&exprFlagName != 0 {
+		d.Name = r.string()
+	}
+	if flags&exprFlagValue != 0 {
+		switch t := r.byte(); t {
+		case exprValueNil:
+		case exprValueFalse:
+			d.Value = false
+		case exprValueTrue:
+			d.Value = true
+		case exprValueNumber:
+			if len(r.buf) < 8 {
+				r.fail("unexpected end")
+				return nil
+			}
+			d.Value = math.Float64frombits(binary.LittleEndian.Uint64(r.buf))
+			r.buf = r.buf[8:]
+		case exprValueString:
+			d.Value = r.string()
+		default:
+			r.fail("unknown value type %d", t)
+		}
+	}
+	if flags&exprFlagTmpl != 0 {
+		d.Texts = r.strings()
+		d.Names = r.strings()
+	}
+	if flags&exprFlagLeft != 0 {
+		d.Left = r.node(depth + 1)
+	}
+	if flags&exprFlagRight != 0 {
+		d.Right = r.node(depth + 1)
+	}
+	if flags&exprFlagArgs != 0 {
+		n := r.count()
+		d.Args = make([]*exprNodeData, n)
+		for i := range d.Args {
+			d.Args[i] = r.node(depth + 1)
+		}
+	}
+	return d
+}
@@ -0,0 +1,174 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytedance/go-tagexpr/v2"
+)
+
+func TestExprMarshal(t *testing.T) {
+	data := map[string]interface{}{
+		"Name":  "alice.go",
+		"Age":   20,
+		"Score": -1.5,
+		"Tags":  []interface{}{"a", "bb", "ccc"},
+		"Nil":   nil,
+		"Addr":  map[string]interface{}{"City": "SH"},
+		"Map":   map[string]interface{}{"k": 1},
+	}
+	cases := []string{
+		"$",
+		"!$",
+		"-(Score)$ + 1 * 2 / 4 % 3 - 0.5",
+		"((Age)$ >= 18 && (Age)$ < 60) || !(Age)$ > 100",
+		"(Age)$ == 20 && (Age)$ != 21 && (Age)$ > 1 && (Age)$ <= 20",
+		"(Name)$ == 'alice.go' && !'' && !!true && !false",
+		"(Nil)$ == nil && !nil",
+		"(Name)$ =~ '^a' && (Name)$ !~ 'x$'",
+		"regexp('^a', (Name)$) && !regexp('\\\\d')",
+		"glob('*.go', (Name)$) && like('al%', (Name)$) && ilike('AL%', (Name)$)",
+		"regexp((Addr.City)$)",
+		"len((Tags)$) + mblen((Name)$) + -len((Tags)$[1])",
+		"(Map)$['k'] + (Tags)$[0+1]",
+		"sprintf('%s is %v', (Name)$, (Age)$)",
+		"range((Tags)$, #k < 2 && len(#v) > 0 && ## == 3)",
+		"!range((Tags)$, -#k)",
+		"range((Tags)$, tmpl('{#k}:{#v}/{##}'))",
+		"tmpl('{Name} is {Age}, {$}')",
+		"tmpl('plain')",
+		"(Age)$old == $ && !$old[0]",
+	}
+	for _, c := range cases {
+		p, err := tagexpr.Parse(c)
+		if !assert.NoError(t, err, c) {
+			continue
+		}
+		want := p.EvalMap(data, "Age").Interface()
+		b, err := json.Marshal(p)
+		if !assert.NoError(t, err, c) {
+			continue
+		}
+		var fromJSON tagexpr.Expr
+		if assert.NoError(t, json.Unmarshal(b, &fromJSON), "%s: %s", c, b) {
+			assert.Equal(t, p.String(), fromJSON.String(), c)
+			assert.Equal(t, p.Tree(), fromJSON.Tree(), c)
+			assert.Equal(t, want, fromJSON.EvalMap(data, "Age").Interface(), c)
+		}
+		b, err = p.MarshalBinary()
+		if !assert.NoError(t, err, c) {
+			continue
+		}
+		var fromBinary tagexpr.Expr
+		if assert.NoError(t, fromBinary.UnmarshalBinary(b), c) {
+			assert.Equal(t, p.String(), fromBinary.String(), c)
+			assert.Equal(t, p.Tree(), fromBinary.Tree(), c)
+			assert.Equal(t, want, fromBinary.EvalMap(data, "Age").Interface(), c)
+		}
+		// the truncated data are rejected, and the corrupted data never load a tree that panics
+		for i := range b {
+			assert.Error(t, new(tagexpr.Expr).UnmarshalBinary(b[:i]), "%s: %d bytes", c, i)
+		}
+		corrupted := make([]byte, len(b))
+		for i := range b {
+			for _, x := range []byte{0, 1, 2, 0x7f, 0xff, b[i] ^ 0x01} {
+				copy(corrupted, b)
+				corrupted[i] = x
+				var loaded tagexpr.Expr
+				if loaded.UnmarshalBinary(corrupted) == nil {
+					assert.NotPanics(t, func() { loaded.EvalMap(data, "Age") }, "%s: byte %d = %#x", c, i, x)
+				}
+			}
+		}
+	}
+
+	p, _ := tagexpr.Parse("(A)$ > 1")
+	b, _ := json.Marshal(p)
+	assert.JSONEq(t, `{"version":1,"source":"(A)$ > 1","expr":{"op":"group","right":{"op":">","left":{"op":"selector","field":"A","name":"$"},"right":{"op":"number","value":1}}}}`, string(b))
+
+	var e tagexpr.Expr
+	err := e.UnmarshalJSON([]byte(`{"version":2,"expr":{"op":"group"}}`))
+	assert.True(t, errors.Is(err, tagexpr.ErrExprFormat))
+	assert.EqualError(t, err, "invalid serialized expression: unsupported version 2, want 1")
+	err = e.UnmarshalJSON([]byte(`{"version":1,"expr":{"op":"group","right":{"op":"func","name":"noSuchFunc"}}}`))
+	assert.True(t, errors.Is(err, tagexpr.ErrUnknownFunc))
+	err = e.UnmarshalJSON([]byte(`{"version":1,"expr":{"op":"group","right":{"op":"&&","left":{"op":"bool","value":true}}}}`))
+	assert.True(t, errors.Is(err, tagexpr.ErrExprFormat))
+	err = e.UnmarshalJSON([]byte(`{"version":1,"expr":{"op":"group","right":{"op":"regexp","name":"regexp","args":[{"op":"group","right":{"op":"string","value":"("}}]}}}`))
+	assert.True(t, errors.Is(err, tagexpr.ErrExprFormat))
+	err = e.UnmarshalBinary([]byte("TGX\x02"))
+	assert.EqualError(t, err, "invalid serialized expression: unsupported version 2, want 1")
+
+	// the nodes missing the required operands or arguments
+	for _, c := range []struct {
+		expr string
+		err  string
+	}{
+		{`{"op":"regexp","name":"regexp","args":[{"op":"group","right":{"op":"string","value":"a"}}]}`, "regexp needs the right operand"},
+		{`{"op":"==","right":{"op":"number","value":1}}`, "== needs the left operand"},
+		{`{"op":"!~","left":{"op":"string","value":"a"}}`, "!~ needs the right operand"},
+		{`{"op":"number","value":1,"left":{"op":"number","value":2}}`, "number has no left operand"},
+		{`{"op":"selector","name":"$","field":"A","right":{"op":"nil"}}`, "selector has no right operand"},
+		{`{"op":"group","left":{"op":"nil"}}`, "group has no left operand"},
+		{`{"op":"bool","value":true,"args":[{"op":"nil"}]}`, "bool has 1 arguments, want 0"},
+		{`{"op":"selector","name":"#","field":"A"}`, `bad selector name "#"`},
+		{`{"op":"range","args":[{"op":"group"}]}`, "range has 1 arguments, want 2"},
+		{`{"op":"regexp","name":"regexp","right":{"op":"nil"}}`, "regexp has 0 patterns, want 1"},
+		{`{"op":"func","name":"len","args":[{"op":"group","right":{"op":"nil"}},{"op":"group","right":{"op":"nil"}}]}`, "wrong number of function arguments: len has 2, want 1"},
+		{`{"op":"selector","name":"$","field":"A","args":[null]}`, "nil argument 0 of selector"},
+		{`{"op":"tmpl","name":"{A}","texts":[""],"args":[{"op":"group"}]}`, "tmpl has 1 texts and 0 names for 1 placeholders"},
+		{`{"op":"?"}`, `unknown node "?"`},
+	} {
+		err = e.UnmarshalJSON([]byte(`{"version":1,"expr":{"op":"group","right":` + c.expr + `}}`))
+		assert.True(t, errors.Is(err, tagexpr.ErrExprFormat), c.expr)
+		assert.EqualError(t, err, "invalid serialized expression: "+c.err, c.expr)
+	}
+	err = e.UnmarshalJSON([]byte(`{"version":1,"expr":{"op":"number","value":1}}`))
+	assert.EqualError(t, err, "invalid serialized expression: the root is not a group")
+}
+
+func TestCompiledExprMarshal(t *testing.T) {
+	c := tagexpr.MustCompile("(Age)$ >= 18   && (Country)$ == 'DE'")
+	for _, marshal := range []func() ([]byte, error){c.MarshalJSON, c.MarshalBinary} {
+		b, err := marshal()
+		if !assert.NoError(t, err) {
+			continue
+		}
+		var loaded tagexpr.CompiledExpr
+		if b[0] == '{' {
+			err = json.Unmarshal(b, &loaded)
+		} else {
+			err = loaded.UnmarshalBinary(b)
+		}
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, c.Source(), loaded.Source())
+		v, err := loaded.Eval(&compileUser{Age: 20, Country: "DE"})
+		assert.NoError(t, err)
+		assert.True(t, v.Bool())
+		_, err = loaded.Eval(&compileOrder{Age: 20})
+		assert.True(t, errors.Is(err, tagexpr.ErrFieldSelector))
+	}
+
+	var loaded tagexpr.CompiledExpr
+	err := loaded.UnmarshalJSON([]byte(`{"version":1,"expr":{"op":"group","right":{"op":"selector","name":"$"}}}`))
+	assert.EqualError(t, err, `syntax error: "$" the current field $ is not available, use (Field)$`)
+}
@@ -0,0 +1,214 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// String returns the normalized source text of the expression.
+func (p *Expr) String() string {
+	return formatExprNode(p.expr)
+}
+
+// Tree returns the indented text of the syntax tree,
+// one node per line with the operands or arguments indented below it.
+func (p *Expr) Tree() string {
+	var b strings.Builder
+	writeExprTree(&b, p.expr, 0)
+	return b.String()
+}
+
+func writeExprTree(b *strings.Builder, e ExprNode, depth int) {
+	if g, ok := e.(*groupExprNode); ok && !g.paren {
+		if g.rightOperand != nil {
+			writeExprTree(b, g.rightOperand, depth)
+		}
+		return
+	}
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(formatExprNode(e))
+	b.WriteByte('\n')
+	for _, sub := range subExprNodes(e) {
+		writeExprTree(b, sub, depth+1)
+	}
+}
+
+// formatExprNode returns the normalized source text of the expression node.
+func formatExprNode(e ExprNode) string {
+	var b strings.Builder
+	writeExprNode(&b, e)
+	return b.String()
+}
+
+func writeExprNode(b *strings.Builder, e ExprNode) {
+	switch t := e.(type) {
+	case nil:
+	case *groupExprNode:
+		if !t.paren {
+			writeExprNode(b, t.rightOperand)
+			return
+		}
+		writeOpposite(b, t.boolOpposite, t.signOpposite)
+		b.WriteByte('(')
+		writeExprNode(b, t.rightOperand)
+		b.WriteByte(')')
+	case *boolExprNode:
+		b.WriteString(strconv.FormatBool(t.val))
+	case *stringExprNode, *digitalExprNode, *nilExprNode:
+		writeLiteral(b, exprNodeLiteral(t))
+	case *selectorExprNode:
+		writeOpposite(b, t.boolOpposite, t.signOpposite)
+		if t.field != "" {
+			b.WriteString("(" + t.field + ")")
+		}
+		b.WriteString(t.name)
+		for _, sub := range t.subExprs {
+			b.WriteByte('[')
+			writeExprNode(b, sub)
+			b.WriteByte(']')
+		}
+	case *rangeKvExprNode:
+		writeOpposite(b, t.boolOpposite, t.signOpposite)
+		b.WriteString(string(t.ctxKey))
+	case *funcExprNode:
+		writeOpposite(b, t.boolOpposite, t.signOpposite)
+		writeCall(b, t.name, t.args...)
+	case *rangeFuncExprNode:
+		writeOpposite(b, t.boolOpposite, t.signOpposite)
+		writeCall(b, "range", t.object, t.elemExprNode)
+	case *regexpFuncExprNode:
+		if t.boolOpposite {
+			b.WriteByte('!')
+		}
+		writeCall(b, t.name, t.pattern, t.rightOperand)
+	case *sprintfFuncExprNode:
+		b.WriteString("sprintf(")
+		writeLiteral(b, t.format)
+		for _, arg := range t.args {
+			b.WriteString(", ")
+			writeExprNode(b, arg)
+		}
+		b.WriteByte(')')
+	case *tmplExprNode:
+		b.WriteString("tmpl(")
+		writeLiteral(b, t.format)
+		b.WriteByte(')')
+	default:
+		if op := operatorSymbol(e); op != "" {
+			writeExprNode(b, e.LeftOperand())
+			b.WriteString(" " + op + " ")
+			writeExprNode(b, e.RightOperand())
+		}
+	}
+}
+
+func writeCall(b *strings.Builder, funcName string, args ...ExprNode) {
+	b.WriteString(funcName)
+	b.WriteByte('(')
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeExprNode(b, arg)
+	}
+	b.WriteByte(')')
+}
+
+func writeOpposite(b *strings.Builder, boolOpposite, signOpposite *bool) {
+	if boolOpposite != nil {
+		if *boolOpposite {
+			b.WriteByte('!')
+		} else {
+			b.WriteString("!!")
+		}
+	}
+	if signOpposite != nil {
+		if *signOpposite {
+			b.WriteByte('-')
+		} else {
+			b.WriteString("--")
+		}
+	}
+}
+
+// exprNodeLiteral returns the value of the literal node.
+func exprNodeLiteral(e ExprNode) interface{} {
+	switch t := e.(type) {
+	case *stringExprNode:
+		return t.val
+	case *digitalExprNode:
+		return t.val
+	case *nilExprNode:
+		return t.val
+	case *boolExprNode:
+		return t.val
+	}
+	return nil
+}
+
+func writeLiteral(b *strings.Builder, v interface{}) {
+	switch t := v.(type) {
+	case nil:
+		b.WriteString("nil")
+	case bool:
+		b.WriteString(strconv.FormatBool(t))
+	case float64:
+		b.WriteString(strconv.FormatFloat(t, 'f', -1, 64))
+	case string:
+		b.WriteByte('\'')
+		b.WriteString(strings.Replace(t, "'", "\\'", -1))
+		b.WriteByte('\'')
+	}
+}
+
+// operatorSymbol returns the symbol of the binary operator node, or "" if it is not an operator.
+func operatorSymbol(e ExprNode) string {
+	switch t := e.(type) {
+	case *additionExprNode:
+		return "+"
+	case *subtractionExprNode:
+		return "-"
+	case *multiplicationExprNode:
+		return "*"
+	case *divisionExprNode:
+		return "/"
+	case *remainderExprNode:
+		return "%"
+	case *equalExprNode:
+		return "=="
+	case *notEqualExprNode:
+		return "!="
+	case *greaterExprNode:
+		return ">"
+	case *greaterEqualExprNode:
+		return ">="
+	case *lessExprNode:
+		return "<"
+	case *lessEqualExprNode:
+		return "<="
+	case *andExprNode:
+		return "&&"
+	case *orExprNode:
+		return "||"
+	case *matchExprNode:
+		if t.opposite {
+			return "!~"
+		}
+		return "=~"
+	}
+	return ""
+}
@@ -15,6 +15,7 @@
 package tagexpr
 
 import (
+	"errors"
 	"math"
 	"reflect"
 	"testing"
@@ -54,6 +55,7 @@ func TestExpr(t *testing.T) {
 		{expr: "10-7-2", val: 1.0},
 		{expr: "20/2", val: 10.0},
 		{expr: "1/0", val: math.NaN()},
+		{expr: "1%0.5", val: math.NaN()},
 		{expr: "20%2", val: 0.0},
 		{expr: "6 % 5", val: 1.0},
 		{expr: "20%7 %5", val: 1.0},
@@ -172,12 +174,26 @@ func TestBuiltInFunc(t *testing.T) {
 		{expr: "regexp('^a\\d$','a0')", val: true},
 		{expr: "regexp('a\\d','a')", val: false},
 		{expr: "regexp('^a\\d$','a')", val: false},
+		{expr: "regexp('^'+'a','a')", val: true},
+		{expr: "'a0' =~ 'a\\d'", val: true},
+		{expr: "'a' =~ 'a\\d'", val: false},
+		{expr: "'a' !~ 'a\\d'", val: true},
+		{expr: "'a'+'0' =~ '^a'+'\\d$' && true", val: true},
 
 		{expr: "sprintf('test string: %s','a')", val: "test string: a"},
 		{expr: "sprintf('test string: %s','a'+'b')", val: "test string: ab"},
 		{expr: "sprintf('test string: %s,%v','a',1)", val: "test string: a,1"},
 		{expr: "sprintf('')+'a'", val: "a"},
 		{expr: "sprintf('%v',10+2*2)", val: "14"},
+
+		{expr: "hasPrefix('abc','ab')", val: true},
+		{expr: "hasSuffix('abc','ab')", val: false},
+		{expr: "contains('abc','b') && !contains('abc','d')", val: true},
+		{expr: "hasPrefix(1,'1')", val: false},
+		{expr: "lower('AbC')+upper('d')", val: "abcD"},
+		{expr: "lower(1)", val: nil},
+		{expr: "sum(nil)", val: 0.0},
+		{expr: "sum('a')", val: nil},
 	}
 	for _, c := range cases {
 		t.Log(c.expr)
@@ -195,6 +211,54 @@ func TestBuiltInFunc(t *testing.T) {
 	}
 }
 
+func TestBuiltInFuncOverride(t *testing.T) {
+	for _, funcName := range []string{
+		"regexpFind", "regexpReplace",
+		"glob", "like", "ilike", "tmpl",
+		"hasPrefix", "hasSuffix", "contains",
+		"int", "float", "string", "bool", "parseInt", "formatFloat",
+		"lower", "upper", "sum",
+	} {
+		testBuiltInFuncOverride(t, funcName)
+	}
+	if err := RegFunc("len", func(...interface{}) interface{} { return nil }); err == nil {
+		t.Fatal("expect the duplicate registration error: len")
+	}
+}
+
+// testBuiltInFuncOverride registers the custom function of the same name as the built-in function without force,
+// then restores the built-in function.
+func testBuiltInFuncOverride(t *testing.T, funcName string) {
+	parse := funcList[funcName]
+	fn, hasFn := funcFns[funcName]
+	defer func() {
+		funcList[funcName] = parse
+		if hasFn {
+			funcFns[funcName] = fn
+		} else {
+			delete(funcFns, funcName)
+		}
+		builtinFuncs[funcName] = true
+	}()
+	if err := RegFunc(funcName, func(...interface{}) interface{} { return "custom" }); err != nil {
+		t.Fatal(err)
+	}
+	vm, err := parseExpr(funcName + "('a', 'b', 'c')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val := vm.run("", nil); val != "custom" {
+		t.Fatalf("%s: got: %v, expect: custom", funcName, val)
+	}
+	tr := NewSQLTranslator(func(fieldSelector string) (string, error) { return fieldSelector, nil })
+	if _, _, err = tr.Translate(funcName + "((A)$, 'a')"); !errors.Is(err, ErrSQLUntranslatable) {
+		t.Fatalf("%s: got: %v, expect: %v", funcName, err, ErrSQLUntranslatable)
+	}
+	if err = RegFunc(funcName, func(...interface{}) interface{} { return nil }); err == nil {
+		t.Fatalf("expect the duplicate registration error: %s", funcName)
+	}
+}
+
 func TestSyntaxIncorrect(t *testing.T) {
 	var cases = []struct {
 		incorrectExpr string
@@ -203,7 +267,6 @@ func TestSyntaxIncorrect(t *testing.T) {
 		{incorrectExpr: "len"},
 		{incorrectExpr: "regexp"},
 		{incorrectExpr: "regexp()"},
-		{incorrectExpr: "regexp('^'+'a','a')"},
 		{incorrectExpr: "regexp('^a','a','b')"},
 		{incorrectExpr: "sprintf()"},
 		{incorrectExpr: "sprintf(0)"},
@@ -218,3 +281,28 @@ func TestSyntaxIncorrect(t *testing.T) {
 		}
 	}
 }
+
+func TestParse(t *testing.T) {
+	expr, err := Parse("(a)$>1 && len($)<3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := expr.String(); s != "(a)$ > 1 && len($) < 3" {
+		t.Fatalf("String: %s", s)
+	}
+	const tree = `(a)$ > 1 && len($) < 3
+  (a)$ > 1
+    (a)$
+    1
+  len($) < 3
+    len($)
+      $
+    3
+`
+	if s := expr.Tree(); s != tree {
+		t.Fatalf("Tree:\n%s", s)
+	}
+	if _, err = Parse("(("); err == nil {
+		t.Fatal("expect syntax incorrect: ((")
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"reflect"
+
+	"github.com/bytedance/go-tagexpr/v2/internal/exprtree"
+)
+
+// exposes the parsed expressions and the tags to the code generator and the static check, see package exprtree
+func init() {
+	exprtree.Parse = func(expr string) (*exprtree.Node, error) {
+		p, err := parseExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		return encodeExprNode(p.expr)
+	}
+	exprtree.Format = func(n *exprtree.Node) string {
+		e, err := decodeExprNode(n)
+		if err != nil {
+			return ""
+		}
+		return formatExprNode(e)
+	}
+	exprtree.Pattern = func(n *exprtree.Node) (string, bool) {
+		e, err := decodeExprNode(n)
+		if err != nil {
+			return "", false
+		}
+		re, ok := e.(*regexpFuncExprNode)
+		if !ok || re.re == nil {
+			return "", false
+		}
+		return re.re.String(), true
+	}
+	exprtree.ReadTags = func(vm interface{}, field reflect.StructField) (string, map[string]string, error) {
+		return vm.(*VM).readTags(field)
+	}
+	exprtree.TagNames = func(vm interface{}) []string {
+		return vm.(*VM).tagNames
+	}
+	exprtree.Check = checkExpr
+}
@@ -10,4 +10,6 @@ require (
 	github.com/stretchr/testify v1.5.1
 	github.com/tidwall/gjson v1.9.3
 	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v2 v2.2.8 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
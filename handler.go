@@ -56,21 +56,11 @@ func (f *FieldHandler) StructField() reflect.StructField {
 // ExprHandler expr handler
 type ExprHandler struct {
 	base       string
-	path       string
 	selector   string
 	expr       *TagExpr
 	targetExpr *TagExpr
 }
 
-func newExprHandler(te, tte *TagExpr, base, es string) *ExprHandler {
-	return &ExprHandler{
-		base:       base,
-		selector:   es,
-		expr:       te,
-		targetExpr: tte,
-	}
-}
-
 // TagExpr returns the *TagExpr.
 func (e *ExprHandler) TagExpr() *TagExpr {
 	return e.expr
@@ -88,14 +78,10 @@ func (e *ExprHandler) ExprSelector() ExprSelector {
 
 // Path returns the path description of the expression.
 func (e *ExprHandler) Path() string {
-	if e.path == "" {
-		if e.targetExpr.path == "" {
-			e.path = e.selector
-		} else {
-			e.path = e.targetExpr.path + FieldSeparator + e.selector
-		}
+	if e.targetExpr.path == "" {
+		return e.selector
 	}
-	return e.path
+	return e.targetExpr.path + FieldSeparator + e.selector
 }
 
 // Eval evaluate the value of the struct tag expression.
@@ -105,12 +91,16 @@ func (e *ExprHandler) Eval() interface{} {
 	return e.expr.s.exprs[e.selector].run(e.base, e.targetExpr)
 }
 
+// Explain evaluates the struct tag expression and returns the trace tree.
+func (e *ExprHandler) Explain() *ExprTrace {
+	return e.expr.s.exprs[e.selector].explain(e.base, e.targetExpr)
+}
+
 // EvalFloat evaluates the value of the struct tag expression.
 // NOTE:
-//  If the expression value type is not float64, return 0.
+//  If the expression value type is not float64 or Integer, return 0.
 func (e *ExprHandler) EvalFloat() float64 {
-	r, _ := e.Eval().(float64)
-	return r
+	return evalFloat(e.Eval())
 }
 
 // EvalString evaluates the value of the struct tag expression.
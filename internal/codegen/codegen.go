@@ -0,0 +1,1052 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codegen generates the static validation code of the struct tags, see cmd/tagexpr-gen.
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
+	"github.com/bytedance/go-tagexpr/v2/internal/exprtree"
+	"github.com/bytedance/go-tagexpr/v2/internal/gentype"
+)
+
+// ValidatorImportPath the import path of the validator package used by the generated code
+const ValidatorImportPath = "github.com/bytedance/go-tagexpr/v2/validator"
+
+// ErrGenUnsupported the expression or the field type can not be translated into static code
+var ErrGenUnsupported = errors.New("unsupported by the code generation")
+
+// ErrGenNothing none of the struct types has validation expressions
+var ErrGenNothing = errors.New("no struct type has validation expressions")
+
+// GenerateValidate generates the source file of the package declaring a Validate() error method
+// for every named struct type in @structs and the named struct types nested in them that have validation expressions.
+// NOTE:
+//  The generated methods have the same semantics as the Validate method of the validator
+//  created by validator.New with the tag names of the VM, and return *validator.Error;
+//  If a struct type uses the expressions or the field types that can not be translated,
+//  e.g. custom functions, pointer scalars or interfaces, its method falls back to the validator at runtime;
+//  The expressions defined by AddRules and For are not included.
+func GenerateValidate(vm *tagexpr.VM, pkgName string, structs []*gentype.Struct) ([]byte, error) {
+	g, err := newValidateGenerator(vm, structs)
+	if err != nil {
+		return nil, err
+	}
+	return g.generate(pkgName)
+}
+
+// GenerateConformanceTest generates the test file of the package that cross-checks the methods
+// generated by GenerateValidate against the validator on the zero values and the random sample values.
+func GenerateConformanceTest(vm *tagexpr.VM, pkgName string, structs []*gentype.Struct) ([]byte, error) {
+	g, err := newValidateGenerator(vm, structs)
+	if err != nil {
+		return nil, err
+	}
+	return g.generateConformanceTest(pkgName)
+}
+
+type validateGenerator struct {
+	vm       *tagexpr.VM
+	list     []*gentype.Struct // the named struct types to generate, in order
+	structs  map[*gentype.Struct]*genStruct
+	imports  map[string]bool
+	helpers  map[string]bool
+	regexps  []string
+	fallback bool
+}
+
+type genStruct struct {
+	*gentype.Struct
+	fields []*genField
+	err    error // the reason of falling back to the validator at runtime
+	body   [2]bytes.Buffer
+}
+
+type genField struct {
+	*gentype.Field
+	tagOp string
+	exprs map[string]string // expression name -> expression string
+}
+
+func newValidateGenerator(vm *tagexpr.VM, structs []*gentype.Struct) (*validateGenerator, error) {
+	g := &validateGenerator{
+		vm:      vm,
+		structs: make(map[*gentype.Struct]*genStruct, len(structs)),
+		imports: make(map[string]bool, 8),
+		helpers: make(map[string]bool, 8),
+	}
+	for _, s := range structs {
+		if s.Name == "" {
+			return nil, fmt.Errorf("tagexpr: the struct type to generate must be a named type of the package")
+		}
+		if err := g.collect(s, map[*gentype.Struct]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	list := g.list[:0]
+	for _, s := range g.list {
+		if g.needValidate(s, map[*gentype.Struct]bool{}) {
+			list = append(list, s)
+		}
+	}
+	g.list = list
+	if len(g.list) == 0 {
+		return nil, ErrGenNothing
+	}
+	for _, s := range g.list {
+		if gs := g.structs[s]; gs.err == nil {
+			g.translateStruct(gs)
+		}
+	}
+	for _, s := range g.list {
+		if err := g.unsupported(s, map[*gentype.Struct]bool{}); err != nil && g.structs[s].err == nil {
+			g.structs[s].err = err
+		}
+	}
+	return g, nil
+}
+
+// collect reads the tags of the struct type and the nested struct types,
+// and appends the named ones to the list.
+func (g *validateGenerator) collect(s *gentype.Struct, seen map[*gentype.Struct]bool) error {
+	if _, ok := g.structs[s]; ok || seen[s] {
+		return nil
+	}
+	seen[s] = true
+	if s.Name != "" {
+		g.list = append(g.list, s)
+	}
+	gs := &genStruct{Struct: s}
+	for _, f := range s.Fields {
+		tagOp, kvs, err := exprtree.ReadTags(g.vm, reflect.StructField{Name: f.Name, Tag: f.Tag})
+		if err != nil {
+			return &tagexpr.TagError{Type: s.Name, Field: f.Name, Err: err}
+		}
+		for _, name := range sortedMapKeys(kvs) {
+			// the custom functions may be registered only at runtime
+			if _, err = exprtree.Parse(kvs[name]); err != nil && gs.err == nil {
+				gs.err = genFieldError(s, f.Name, fmt.Errorf("%w: %v", ErrGenUnsupported, err))
+			}
+		}
+		gs.fields = append(gs.fields, &genField{Field: f, tagOp: tagOp, exprs: kvs})
+		for _, sub := range nestedStructs(f.Type) {
+			if err = g.collect(sub, seen); err != nil {
+				return err
+			}
+		}
+	}
+	g.structs[s] = gs
+	return nil
+}
+
+// nestedStructs returns the struct types nested in the field type.
+func nestedStructs(t *gentype.Type) []*gentype.Struct {
+	switch t.Kind {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return nestedStructs(t.Elem)
+	case reflect.Map:
+		return append(nestedStructs(t.Key), nestedStructs(t.Elem)...)
+	case reflect.Struct:
+		return []*gentype.Struct{t.Struct}
+	}
+	return nil
+}
+
+// needValidate returns whether the struct type or its nested struct types have validation expressions,
+// or the fields that can only be validated at runtime.
+func (g *validateGenerator) needValidate(s *gentype.Struct, seen map[*gentype.Struct]bool) bool {
+	if seen[s] {
+		return false
+	}
+	seen[s] = true
+	gs := g.structs[s]
+	if gs == nil {
+		return false
+	}
+	for _, f := range gs.fields {
+		if _, ok := f.exprs[tagexpr.DefaultExprName]; ok {
+			return true
+		}
+		if f.tagOp == exprtree.TagOmit && derefType(f.Type).Kind != reflect.Struct {
+			continue
+		}
+		if f.Type.Kind == reflect.Interface || derefType(f.Type).Kind == reflect.Interface {
+			return true
+		}
+		for _, sub := range nestedStructs(f.Type) {
+			if g.needValidate(sub, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unsupported returns the reason why the struct type or its nested struct types can not be translated.
+func (g *validateGenerator) unsupported(s *gentype.Struct, seen map[*gentype.Struct]bool) error {
+	if seen[s] {
+		return nil
+	}
+	seen[s] = true
+	gs := g.structs[s]
+	if gs.err != nil {
+		return gs.err
+	}
+	for _, f := range gs.fields {
+		for _, sub := range nestedStructs(f.Type) {
+			if !g.needValidate(sub, map[*gentype.Struct]bool{}) {
+				continue
+			}
+			if sub.Name == "" {
+				return genFieldError(s, f.Name, fmt.Errorf("%w: unnamed or foreign struct type", ErrGenUnsupported))
+			}
+			if err := g.unsupported(sub, seen); err != nil {
+				return genFieldError(s, f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// needNested returns whether the type nests the struct types that need validation.
+func (g *validateGenerator) needNested(t *gentype.Type) bool {
+	for _, sub := range nestedStructs(t) {
+		if g.needValidate(sub, map[*gentype.Struct]bool{}) {
+			return true
+		}
+	}
+	return false
+}
+
+func genFieldError(s *gentype.Struct, field string, err error) error {
+	return fmt.Errorf("%s.%s: %w", s.Name, field, err)
+}
+
+func derefType(t *gentype.Type) *gentype.Type {
+	for t.Kind == reflect.Ptr {
+		t = t.Elem
+	}
+	return t
+}
+
+// translateStruct translates the validation of the struct type into the bodies of the methods
+// that validate the direct fields and the elements of the indirect fields.
+func (g *validateGenerator) translateStruct(gs *genStruct) {
+	fields, elems := &gs.body[0], &gs.body[1]
+	for _, f := range gs.fields {
+		if src, ok := f.exprs[tagexpr.DefaultExprName]; ok {
+			if err := g.translateCheck(fields, gs, f, src); err != nil {
+				gs.err = genFieldError(gs.Struct, f.Name, err)
+				return
+			}
+		}
+		t := f.Type
+		switch t.Kind {
+		case reflect.Interface:
+			if f.tagOp != exprtree.TagOmit {
+				gs.err = genFieldError(gs.Struct, f.Name, fmt.Errorf("%w: interface", ErrGenUnsupported))
+				return
+			}
+		case reflect.Ptr, reflect.Struct:
+			if t.Kind == reflect.Ptr {
+				t = t.Elem
+			}
+			switch t.Kind {
+			case reflect.Struct:
+				if !g.needValidate(t.Struct, map[*gentype.Struct]bool{}) {
+					continue
+				}
+				// the nested expressions of the omitted field are ignored, but not the elements
+				if f.tagOp != exprtree.TagOmit {
+					writeGenNestedCall(fields, f, "tagexprValidateFields")
+				}
+				writeGenNestedCall(elems, f, "tagexprValidateElems")
+			case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+				if f.tagOp != exprtree.TagOmit && (derefType(t).Kind == reflect.Interface || g.needNested(t)) {
+					gs.err = genFieldError(gs.Struct, f.Name, fmt.Errorf("%w: pointer to %s", ErrGenUnsupported, t.Kind))
+					return
+				}
+			}
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if f.tagOp == exprtree.TagOmit {
+				continue
+			}
+			if err := g.translateElems(elems, f); err != nil {
+				gs.err = genFieldError(gs.Struct, f.Name, err)
+				return
+			}
+		}
+	}
+}
+
+// writeGenNestedCall writes the call of the method of the nested struct field, which is skipped if the field is nil.
+func writeGenNestedCall(b *bytes.Buffer, f *genField, method string) {
+	recv := "x." + f.Name
+	if f.Type.Kind == reflect.Ptr {
+		fmt.Fprintf(b, "if %s != nil {\n", recv)
+		defer b.WriteString("}\n")
+	}
+	writeGenCall(b, recv, method, strconv.Quote(f.Name+tagexpr.FieldSeparator))
+}
+
+func writeGenCall(b *bytes.Buffer, recv, method, pathArg string) {
+	fmt.Fprintf(b, "if err := %s.%s(path + %s); err != nil {\nreturn err\n}\n", recv, method, pathArg)
+}
+
+// translateElems translates the validation of the elements of the slice, array or map field,
+// which are validated in reverse order like the validator.
+func (g *validateGenerator) translateElems(b *bytes.Buffer, f *genField) error {
+	t := f.Type
+	if t.Kind == reflect.Map {
+		if k := derefType(t.Key); k.Kind == reflect.Interface || g.needNested(k) {
+			return fmt.Errorf("%w: map key type", ErrGenUnsupported)
+		}
+	}
+	elem := t.Elem
+	isPtr := elem.Kind == reflect.Ptr
+	if isPtr {
+		elem = elem.Elem
+	}
+	switch elem.Kind {
+	case reflect.Struct:
+		if !g.needValidate(elem.Struct, map[*gentype.Struct]bool{}) {
+			return nil
+		}
+	case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		if elem.Kind == reflect.Interface || g.needNested(elem) {
+			return fmt.Errorf("%w: element type", ErrGenUnsupported)
+		}
+		return nil
+	default:
+		return nil
+	}
+	if isPtr && f.tagOp != exprtree.TagOmitNil {
+		// the validator evaluates the expressions of the nil element with nil values
+		return fmt.Errorf("%w: nil struct pointer elements without the '?' tag", ErrGenUnsupported)
+	}
+	if t.Kind == reflect.Map {
+		key := "string(k)"
+		if t.Key.Kind != reflect.String {
+			g.imports["reflect"] = true
+			key = "reflect.ValueOf(k).String()"
+		}
+		fmt.Fprintf(b, "for k, v := range x.%s {\n", f.Name)
+		if isPtr {
+			b.WriteString("if v == nil {\ncontinue\n}\n")
+		}
+		writeGenCall(b, "v", "tagexprValidate", fmt.Sprintf(`%q + %s + "}."`, f.Name+"{v for k=", key))
+		b.WriteString("}\n")
+		return nil
+	}
+	g.imports["strconv"] = true
+	fmt.Fprintf(b, "for i := len(x.%s) - 1; i >= 0; i-- {\n", f.Name)
+	if isPtr {
+		fmt.Fprintf(b, "if x.%s[i] == nil {\ncontinue\n}\n", f.Name)
+	}
+	writeGenCall(b, fmt.Sprintf("x.%s[i]", f.Name), "tagexprValidate", fmt.Sprintf(`%q + strconv.Itoa(i) + "]."`, f.Name+"["))
+	b.WriteString("}\n")
+	return nil
+}
+
+// translateCheck translates the validation expression of the field and its message expression.
+func (g *validateGenerator) translateCheck(b *bytes.Buffer, gs *genStruct, f *genField, src string) error {
+	e, err := exprtree.Parse(src)
+	if err != nil {
+		return err
+	}
+	v, err := g.translate(e, gs.Struct, f.Name)
+	if err == nil {
+		v, err = g.fakeBool(v)
+	}
+	if err != nil {
+		return err
+	}
+	msg := `""`
+	if src, ok := f.exprs[genMsgExprName]; ok {
+		e, err := exprtree.Parse(src)
+		if err != nil {
+			return err
+		}
+		m, err := g.translate(e, gs.Struct, f.Name)
+		if err != nil {
+			return err
+		}
+		// the message is empty if it is not a string
+		if m.kind == genString {
+			msg = m.code
+		}
+	}
+	g.imports[ValidatorImportPath] = true
+	// the indent keeps the quotes of the expression from being reformatted
+	fmt.Fprintf(b, "\t// %s: %s\n", f.Name, exprtree.Format(e))
+	fmt.Fprintf(b, "if !(%s) {\nreturn &validator.Error{FailPath: path + %q, Msg: %s}\n}\n", v.code, f.Name, msg)
+	return nil
+}
+
+// genMsgExprName the name of the expression used to specify the message, the same as the validator
+const genMsgExprName = "msg"
+
+type genKind uint8
+
+const (
+	genNumber genKind = iota + 1
+	genString
+	genBool
+	genList // slice, array or map, only used as the argument of len() and mblen()
+)
+
+// genValue the Go code of the expression value, which is of the static type
+type genValue struct {
+	code string
+	kind genKind
+}
+
+func genUnsupported(e *exprtree.Node) error {
+	return fmt.Errorf("%w: %s", ErrGenUnsupported, exprtree.Format(e))
+}
+
+// translate translates the expression node into the Go code with the same result as Run.
+func (g *validateGenerator) translate(e *exprtree.Node, s *gentype.Struct, currField string) (genValue, error) {
+	switch e.Op {
+	case "group":
+		if e.Right == nil {
+			return genValue{}, genUnsupported(e)
+		}
+		v, err := g.translate(e.Right, s, currField)
+		if err != nil {
+			return v, err
+		}
+		return g.opposite(v, e.Not, e.Neg)
+	case "bool", "string", "number":
+		switch v := e.Value.(type) {
+		case bool:
+			return genValue{code: strconv.FormatBool(v), kind: genBool}, nil
+		case string:
+			return genValue{code: strconv.Quote(v), kind: genString}, nil
+		case float64:
+			return genValue{code: "float64(" + strconv.FormatFloat(v, 'g', -1, 64) + ")", kind: genNumber}, nil
+		}
+	case "selector":
+		if len(e.Args) > 0 || e.Name == oldSelector {
+			return genValue{}, genUnsupported(e)
+		}
+		field := e.Field
+		if field == "" {
+			field = currField
+		}
+		v, err := g.selector(s, field)
+		if err != nil {
+			return v, err
+		}
+		return g.opposite(v, e.Not, e.Neg)
+	case "func":
+		if (e.Name != "len" && e.Name != "mblen") || len(e.Args) != 1 {
+			return genValue{}, genUnsupported(e)
+		}
+		v, err := g.translate(e.Args[0], s, currField)
+		if err != nil {
+			return v, err
+		}
+		switch v.kind {
+		case genString:
+			if e.Name == "mblen" {
+				g.imports["unicode/utf8"] = true
+				v.code = "float64(utf8.RuneCountInString(" + v.code + "))"
+			} else {
+				v.code = "float64(len(" + v.code + "))"
+			}
+		case genList:
+			v.code = "float64(len(" + v.code + "))"
+		default:
+			v.code = "float64(0)"
+		}
+		v.kind = genNumber
+		return g.opposite(v, e.Not, e.Neg)
+	case "regexp":
+		pattern, ok := exprtree.Pattern(e)
+		if !ok {
+			return genValue{}, genUnsupported(e)
+		}
+		v, err := g.translate(e.Right, s, currField)
+		if err != nil {
+			return v, err
+		}
+		if v.kind != genString {
+			// the value that is not a string does not match
+			return genValue{code: "false", kind: genBool}, nil
+		}
+		code := g.regexp(pattern) + ".MatchString(" + v.code + ")"
+		if e.Not != nil && *e.Not {
+			code = "!" + code
+		}
+		return genValue{code: code, kind: genBool}, nil
+	case "=~", "!~":
+		r := unwrapExprNode(e.Right)
+		pattern, ok := r.Value.(string)
+		if !ok || r.Op != "string" {
+			return genValue{}, genUnsupported(e)
+		}
+		v, err := g.translate(e.Left, s, currField)
+		if err != nil {
+			return v, err
+		}
+		if _, err = regexp.Compile(pattern); err != nil || v.kind != genString {
+			return genValue{code: "false", kind: genBool}, nil
+		}
+		code := g.regexp(pattern) + ".MatchString(" + v.code + ")"
+		if e.Op == "!~" {
+			code = "!" + code
+		}
+		return genValue{code: code, kind: genBool}, nil
+	case "sprintf":
+		args := []string{strconv.Quote(e.Name)}
+		for _, arg := range e.Args {
+			v, err := g.translate(arg, s, currField)
+			if err != nil {
+				return v, err
+			}
+			if v.kind == genList {
+				return genValue{}, genUnsupported(e)
+			}
+			args = append(args, v.code)
+		}
+		g.imports["fmt"] = true
+		return genValue{code: "fmt.Sprintf(" + strings.Join(args, ", ") + ")", kind: genString}, nil
+	case "+", "-", "*", "/", "%", "==", "!=", ">", ">=", "<", "<=", "&&", "||":
+		l, err := g.translate(e.Left, s, currField)
+		if err != nil {
+			return l, err
+		}
+		r, err := g.translate(e.Right, s, currField)
+		if err != nil {
+			return r, err
+		}
+		return g.operator(e, l, r)
+	}
+	return genValue{}, genUnsupported(e)
+}
+
+// oldSelector the selector of the old value of the transition, which is not translated
+const oldSelector = "$old"
+
+// unwrapExprNode returns the node wrapped by the groups without parentheses and prefixes.
+func unwrapExprNode(e *exprtree.Node) *exprtree.Node {
+	for e.Op == "group" && !e.Paren && e.Not == nil && e.Neg == nil && e.Right != nil {
+		e = e.Right
+	}
+	return e
+}
+
+// selector translates the field selector relative to the struct type.
+func (g *validateGenerator) selector(s *gentype.Struct, field string) (genValue, error) {
+	code := "x"
+	var t *gentype.Type
+	for _, name := range strings.Split(field, tagexpr.FieldSeparator) {
+		if t != nil {
+			if t.Kind != reflect.Struct {
+				return genValue{}, fmt.Errorf("%w: (%s)$: field through pointer", ErrGenUnsupported, field)
+			}
+			s = t.Struct
+		}
+		t = nil
+		for _, f := range s.Fields {
+			if f.Name == name {
+				t = f.Type
+				break
+			}
+		}
+		if t == nil {
+			return genValue{}, fmt.Errorf("%w: (%s)$", tagexpr.ErrFieldSelector, field)
+		}
+		code += tagexpr.FieldSeparator + name
+	}
+	switch t.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return genValue{code: "float64(" + code + ")", kind: genNumber}, nil
+	case reflect.String:
+		return genValue{code: "string(" + code + ")", kind: genString}, nil
+	case reflect.Bool:
+		return genValue{code: "bool(" + code + ")", kind: genBool}, nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return genValue{code: code, kind: genList}, nil
+	}
+	return genValue{}, fmt.Errorf("%w: (%s)$: %s", ErrGenUnsupported, field, t.Kind)
+}
+
+// opposite translates the '!' and '-' prefixes, the same as realValue.
+func (g *validateGenerator) opposite(v genValue, boolOpposite, signOpposite *bool) (genValue, error) {
+	if boolOpposite != nil {
+		b, err := g.fakeBool(v)
+		if err != nil {
+			return b, err
+		}
+		if *boolOpposite {
+			b.code = "!(" + b.code + ")"
+		}
+		return b, nil
+	}
+	if signOpposite != nil && *signOpposite && v.kind == genNumber {
+		v.code = "-(" + v.code + ")"
+	}
+	return v, nil
+}
+
+// fakeBool translates the value into bool, the same as FakeBool.
+func (g *validateGenerator) fakeBool(v genValue) (genValue, error) {
+	switch v.kind {
+	case genNumber:
+		v.code = v.code + " != 0"
+	case genString:
+		v.code = v.code + ` != ""`
+	case genBool:
+	default:
+		return v, fmt.Errorf("%w: boolean value of %s", ErrGenUnsupported, v.code)
+	}
+	v.kind = genBool
+	return v, nil
+}
+
+// toFloat64 translates the value into float64, the same as toFloat64(v, true) ignoring the failure.
+func (g *validateGenerator) toFloat64(v genValue) string {
+	switch v.kind {
+	case genNumber:
+		return v.code
+	case genString:
+		g.helpers["tagexprParseFloat"] = true
+		return "tagexprParseFloat(" + v.code + ")"
+	}
+	return "float64(0)"
+}
+
+// toString translates the value into string, the same as toString(v, true).
+func (g *validateGenerator) toString(v genValue) string {
+	if v.kind == genString {
+		return v.code
+	}
+	g.imports["fmt"] = true
+	return "fmt.Sprint(" + v.code + ")"
+}
+
+// operator translates the binary operator with the same semantics as the Run method of the operator node.
+func (g *validateGenerator) operator(e *exprtree.Node, l, r genValue) (genValue, error) {
+	op := e.Op
+	if l.kind == genList || r.kind == genList {
+		return genValue{}, genUnsupported(e)
+	}
+	l.code, r.code = "("+l.code+")", "("+r.code+")"
+	num := func(code string) (genValue, error) { return genValue{code: code, kind: genNumber}, nil }
+	bol := func(code string) (genValue, error) { return genValue{code: code, kind: genBool}, nil }
+	switch op {
+	case "+":
+		switch l.kind {
+		case genNumber:
+			return num(l.code + " + " + g.toFloat64(r))
+		case genString:
+			return genValue{code: l.code + " + " + g.toString(r), kind: genString}, nil
+		}
+		return l, nil
+	case "-", "*":
+		return num(g.toFloat64(l) + " " + op + " " + g.toFloat64(r))
+	case "/":
+		g.helpers["tagexprDiv"] = true
+		return num("tagexprDiv(" + g.toFloat64(l) + ", " + g.toFloat64(r) + ")")
+	case "%":
+		g.helpers["tagexprMod"] = true
+		return num("tagexprMod(" + g.toFloat64(l) + ", " + g.toFloat64(r) + ")")
+	case "&&", "||":
+		lb, err := g.fakeBool(l)
+		if err != nil {
+			return lb, err
+		}
+		rb, err := g.fakeBool(r)
+		if err != nil {
+			return rb, err
+		}
+		return bol(lb.code + " " + op + " " + rb.code)
+	case "==", "!=":
+		v := g.equal(l, r)
+		if op == "!=" {
+			v.code = "!(" + v.code + ")"
+		}
+		return v, nil
+	}
+	// >, >=, <, <=
+	switch {
+	case l.kind == genNumber && r.kind == genNumber, l.kind == genString:
+		if l.kind == genString {
+			r.code = g.toString(r)
+		}
+		return bol(l.code + " " + op + " " + r.code)
+	case l.kind == genNumber && r.kind == genString:
+		g.helpers["tagexprParseNumber"] = true
+		return bol("func() bool { f, ok := tagexprParseNumber(" + r.code + "); return ok && " + l.code + " " + op + " f }()")
+	}
+	return bol("false")
+}
+
+// equal translates the '==' operator.
+func (g *validateGenerator) equal(l, r genValue) genValue {
+	v := genValue{kind: genBool}
+	switch {
+	case l.kind == r.kind:
+		v.code = l.code + " == " + r.code
+	case l.kind == genNumber && r.kind == genString:
+		g.helpers["tagexprParseNumber"] = true
+		v.code = "func() bool { f, ok := tagexprParseNumber(" + r.code + "); return ok && " + l.code + " == f }()"
+	case l.kind == genString:
+		v.code = l.code + " == " + g.toString(r)
+	default:
+		v.code = "false"
+	}
+	return v
+}
+
+// regexp returns the name of the package-level variable of the compiled pattern.
+func (g *validateGenerator) regexp(pattern string) string {
+	g.imports["regexp"] = true
+	for i, p := range g.regexps {
+		if p == pattern {
+			return "tagexprRegexp" + strconv.Itoa(i)
+		}
+	}
+	g.regexps = append(g.regexps, pattern)
+	return "tagexprRegexp" + strconv.Itoa(len(g.regexps)-1)
+}
+
+var genHelpers = map[string]struct {
+	imports []string
+	code    string
+}{
+	"tagexprParseFloat": {[]string{"strconv"}, `func tagexprParseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}`},
+	"tagexprParseNumber": {[]string{"strconv"}, `func tagexprParseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}`},
+	"tagexprDiv": {[]string{"math"}, `func tagexprDiv(a, b float64) float64 {
+	if b == 0 {
+		return math.NaN()
+	}
+	return a / b
+}`},
+	"tagexprMod": {[]string{"math"}, `func tagexprMod(a, b float64) float64 {
+	if b == 0 {
+		return math.NaN()
+	}
+	return float64(int64(a) % int64(b))
+}`},
+}
+
+const genHeader = "// Code generated by tagexpr-gen. DO NOT EDIT.\n\n"
+
+func (g *validateGenerator) generate(pkgName string) ([]byte, error) {
+	var b bytes.Buffer
+	for _, s := range g.list {
+		gs := g.structs[s]
+		fmt.Fprintf(&b, "\n// Validate validates the fields of %s by the struct tags.\n", s.Name)
+		if gs.err != nil {
+			g.fallback = true
+			g.imports[ValidatorImportPath] = true
+			fmt.Fprintf(&b, "// It falls back to the validator at runtime, since %s.\n", gs.err)
+			fmt.Fprintf(&b, "func (x *%s) Validate() error {\nreturn tagexprValidator.Validate(x)\n}\n", s.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "func (x *%s) Validate() error {\nreturn x.tagexprValidate(\"\")\n}\n", s.Name)
+		fmt.Fprintf(&b, "\nfunc (x *%s) tagexprValidate(path string) error {\n", s.Name)
+		b.WriteString("if err := x.tagexprValidateFields(path); err != nil {\nreturn err\n}\nreturn x.tagexprValidateElems(path)\n}\n")
+		for i, method := range [2]string{"tagexprValidateFields", "tagexprValidateElems"} {
+			fmt.Fprintf(&b, "\nfunc (x *%s) %s(path string) error {\n", s.Name, method)
+			b.Write(gs.body[i].Bytes())
+			b.WriteString("return nil\n}\n")
+		}
+	}
+	var vars bytes.Buffer
+	if g.fallback {
+		fmt.Fprintf(&vars, "\nvar tagexprValidator = validator.New(%s)\n", g.tagNamesCode())
+	}
+	if len(g.regexps) > 0 {
+		vars.WriteString("\nvar (\n")
+		for i, p := range g.regexps {
+			fmt.Fprintf(&vars, "tagexprRegexp%d = regexp.MustCompile(%s)\n", i, strconv.Quote(p))
+		}
+		vars.WriteString(")\n")
+	}
+	for _, name := range sortedMapKeys(g.helpers) {
+		h := genHelpers[name]
+		for _, i := range h.imports {
+			g.imports[i] = true
+		}
+		b.WriteString("\n" + h.code + "\n")
+	}
+	return formatGenSource(pkgName, g.imports, vars.String()+b.String())
+}
+
+func (g *validateGenerator) tagNamesCode() string {
+	tagNames := exprtree.TagNames(g.vm)
+	a := make([]string, len(tagNames))
+	for i, name := range tagNames {
+		a[i] = strconv.Quote(name)
+	}
+	return strings.Join(a, ", ")
+}
+
+func formatGenSource(pkgName string, imports map[string]bool, body string) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(genHeader)
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	paths := make([]string, 0, len(imports))
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	// the standard packages go first
+	sort.SliceStable(paths, func(i, j int) bool {
+		return !strings.Contains(paths[i], ".") && strings.Contains(paths[j], ".")
+	})
+	if len(paths) > 0 {
+		b.WriteString("import (\n")
+		for i, p := range paths {
+			if i > 0 && strings.Contains(p, ".") && !strings.Contains(paths[i-1], ".") {
+				b.WriteString("\n")
+			}
+			b.WriteString(strconv.Quote(p) + "\n")
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteString(body)
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("tagexpr: format the generated code: %w", err)
+	}
+	return src, nil
+}
+
+func (g *validateGenerator) generateConformanceTest(pkgName string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `
+// TestTagexprGenConformance checks that the generated Validate methods return the same errors as the validator.
+func TestTagexprGenConformance(t *testing.T) {
+	vd := validator.New(%s)
+	for _, c := range []struct {
+		name string
+		new  func() interface{ Validate() error }
+	}{
+`, g.tagNamesCode())
+	for _, s := range g.list {
+		fmt.Fprintf(&b, "{%q, func() interface{ Validate() error } { return new(%s) }},\n", s.Name, s.Name)
+	}
+	b.WriteString(`} {
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < tagexprGenSamples; i++ {
+			x := c.new()
+			if i > 0 {
+				tagexprGenFill(r, reflect.ValueOf(x).Elem(), 0)
+			}
+			want, got := vd.Validate(x), x.Validate()
+			if !tagexprGenSameError(want, got) {
+				t.Fatalf("%s: sample %d %+v: the validator returns %v, but Validate returns %v", c.name, i, x, want, got)
+			}
+		}
+	}
+}
+
+const tagexprGenSamples = 10000
+
+`)
+	fmt.Fprintf(&b, "var tagexprGenPkgPath = reflect.TypeOf((*%s)(nil)).Elem().PkgPath()\n", g.list[0].Name)
+	ints, floats, strs := g.samples()
+	fmt.Fprintf(&b, `
+// the sample values, including the literals of the expressions and their neighbors
+var (
+	tagexprGenInts    = []int64{%s}
+	tagexprGenFloats  = []float64{%s}
+	tagexprGenStrings = []string{%s}
+)
+`, strings.Join(ints, ", "), strings.Join(floats, ", "), strings.Join(strs, ", "))
+	b.WriteString(`
+func tagexprGenSameError(want, got error) bool {
+	if want == nil || got == nil {
+		return want == nil && got == nil
+	}
+	if want.Error() != got.Error() {
+		return false
+	}
+	w, ok1 := want.(*validator.Error)
+	g, ok2 := got.(*validator.Error)
+	return ok1 == ok2 && (!ok1 || w.FailPath == g.FailPath)
+}
+
+// tagexprGenFill fills the value with the random samples, the fields of the structs of other packages are skipped.
+func tagexprGenFill(r *rand.Rand, v reflect.Value, depth int) {
+	if !v.CanSet() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(tagexprGenInts[r.Intn(len(tagexprGenInts))])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := tagexprGenInts[r.Intn(len(tagexprGenInts))]
+		if n < 0 {
+			n = -n
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(tagexprGenFloats[r.Intn(len(tagexprGenFloats))])
+	case reflect.String:
+		v.SetString(tagexprGenStrings[r.Intn(len(tagexprGenStrings))])
+	case reflect.Ptr:
+		if depth < 3 && r.Intn(3) > 0 {
+			e := reflect.New(v.Type().Elem())
+			tagexprGenFill(r, e.Elem(), depth+1)
+			v.Set(e)
+		}
+	case reflect.Slice:
+		if depth < 3 && r.Intn(3) > 0 {
+			n := r.Intn(4)
+			s := reflect.MakeSlice(v.Type(), n, n)
+			for i := 0; i < n; i++ {
+				tagexprGenFill(r, s.Index(i), depth+1)
+			}
+			v.Set(s)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			tagexprGenFill(r, v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		// at most one entry, since the validator ranges over the map in random order
+		if depth < 3 && r.Intn(2) == 0 {
+			m := reflect.MakeMap(v.Type())
+			k, e := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+			tagexprGenFill(r, k, depth+1)
+			tagexprGenFill(r, e, depth+1)
+			m.SetMapIndex(k, e)
+			v.Set(m)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" && v.Type().PkgPath() != tagexprGenPkgPath {
+				continue
+			}
+			tagexprGenFill(r, v.Field(i), depth+1)
+		}
+	}
+}
+`)
+	imports := map[string]bool{
+		"math/rand":         true,
+		"reflect":           true,
+		"testing":           true,
+		"unsafe":            true,
+		ValidatorImportPath: true,
+	}
+	return formatGenSource(pkgName, imports, b.String())
+}
+
+// samples returns the Go code of the sample values of the conformance test,
+// including the literals of the expressions and their neighbors.
+func (g *validateGenerator) samples() (ints, floats, strs []string) {
+	intSet := map[int64]bool{0: true, 1: true, -1: true, 2: true, 3: true, 10: true, 100: true, 1000: true}
+	floatSet := map[float64]bool{0: true, 0.5: true, -1.5: true, 2.5: true}
+	strSet := map[string]bool{"": true, "a": true, "ab": true, "abc": true, "Hello World": true, "0": true, "-3": true, "2.5": true, "true": true, "中文": true}
+	for _, s := range g.list {
+		for _, f := range g.structs[s].fields {
+			for _, src := range f.exprs {
+				e, err := exprtree.Parse(src)
+				if err != nil {
+					continue
+				}
+				walkExprNode(e, func(n *exprtree.Node) {
+					switch v := n.Value.(type) {
+					case float64:
+						for _, d := range []float64{-1, -0.5, 0, 0.5, 1} {
+							floatSet[v+d] = true
+						}
+						if i := int64(v); float64(i) == v {
+							intSet[i-1], intSet[i], intSet[i+1] = true, true, true
+						}
+						strSet[strconv.FormatFloat(v, 'f', -1, 64)] = true
+					case string:
+						strSet[v] = true
+						strSet[v+"a"] = true
+					}
+				})
+			}
+		}
+	}
+	for _, i := range sortedGenKeys(intSet) {
+		ints = append(ints, strconv.FormatInt(i.(int64), 10))
+	}
+	for _, f := range sortedGenKeys(floatSet) {
+		floats = append(floats, strconv.FormatFloat(f.(float64), 'g', -1, 64))
+	}
+	for _, s := range sortedMapKeys(strSet) {
+		strs = append(strs, strconv.Quote(s))
+	}
+	return ints, floats, strs
+}
+
+// sortedGenKeys returns the sorted keys of the map with int64 or float64 keys.
+func sortedGenKeys(m interface{}) []interface{} {
+	var keys []interface{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.Interface())
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return reflect.ValueOf(keys[i]).Convert(float64Type).Float() < reflect.ValueOf(keys[j]).Convert(float64Type).Float()
+	})
+	return keys
+}
+
+var float64Type = reflect.TypeOf(float64(0))
+
+// sortedMapKeys returns the sorted keys of the map with string keys.
+func sortedMapKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// walkExprNode calls fn for the node and its sub-nodes in depth-first order.
+func walkExprNode(e *exprtree.Node, fn func(*exprtree.Node)) {
+	if e == nil {
+		return
+	}
+	fn(e)
+	walkExprNode(e.Left, fn)
+	walkExprNode(e.Right, fn)
+	for _, arg := range e.Args {
+		walkExprNode(arg, fn)
+	}
+}
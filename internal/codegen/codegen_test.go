@@ -0,0 +1,72 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
+	"github.com/bytedance/go-tagexpr/v2/internal/gentype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateValidate(t *testing.T) {
+	str := &gentype.Type{Kind: reflect.String}
+	num := &gentype.Type{Kind: reflect.Int}
+	item := &gentype.Struct{Name: "Item", Fields: []*gentype.Field{
+		{Name: "N", Tag: `vd:"$>0"`, Type: num},
+	}}
+	user := &gentype.Struct{Name: "User", Fields: []*gentype.Field{
+		{Name: "Name", Tag: `vd:"len($)>1 && $!='x'; msg:sprintf('bad %v', $)"`, Type: str},
+		{Name: "Items", Type: &gentype.Type{Kind: reflect.Slice, Elem: &gentype.Type{Kind: reflect.Struct, Struct: item}}},
+	}}
+	event := &gentype.Struct{Name: "Event", Fields: []*gentype.Field{
+		{Name: "At", Tag: `vd:"$!=nil"`, Type: &gentype.Type{Kind: reflect.Ptr, Elem: num}},
+	}}
+	src, err := GenerateValidate(tagexpr.New("vd"), "x", []*gentype.Struct{user, event})
+	if !assert.NoError(t, err) {
+		return
+	}
+	s := string(src)
+	t.Log(s)
+	assert.Contains(t, s, `if !(((float64(len(string(x.Name)))) > (float64(1))) && (!((string(x.Name)) == ("x")))) {`)
+	assert.Contains(t, s, `return &validator.Error{FailPath: path + "Name", Msg: fmt.Sprintf("bad %v", string(x.Name))}`)
+	assert.Contains(t, s, `x.Items[i].tagexprValidate(path + "Items[" + strconv.Itoa(i) + "].")`)
+	assert.Contains(t, s, "func (x *Item) Validate() error {\n\treturn x.tagexprValidate(\"\")\n}")
+	assert.Contains(t, s, "since Event.At: unsupported by the code generation: (At)$: ptr.\nfunc (x *Event) Validate() error {\n\treturn tagexprValidator.Validate(x)\n}")
+	assert.Contains(t, s, `var tagexprValidator = validator.New("vd")`)
+
+	_, err = GenerateValidate(tagexpr.New("vd"), "x", []*gentype.Struct{item, {Name: "Plain", Fields: []*gentype.Field{{Name: "X", Type: num}}}})
+	assert.NoError(t, err)
+	_, err = GenerateValidate(tagexpr.New("vd"), "x", []*gentype.Struct{{Name: "Plain", Fields: []*gentype.Field{{Name: "X", Type: num}}}})
+	assert.True(t, errors.Is(err, ErrGenNothing))
+	_, err = GenerateValidate(tagexpr.New("vd"), "x", []*gentype.Struct{{Name: "Bad", Fields: []*gentype.Field{{Name: "X", Tag: `vd:"$>0;$>1"`, Type: num}}}})
+	var tagErr *tagexpr.TagError
+	assert.True(t, errors.As(err, &tagErr))
+
+	glob := &gentype.Struct{Name: "Glob", Fields: []*gentype.Field{{Name: "S", Tag: `vd:"glob('a*', $)"`, Type: str}}}
+	src, err = GenerateValidate(tagexpr.New("vd"), "x", []*gentype.Struct{glob})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(src), `tagexprRegexp0 = regexp.MustCompile("(?s)^a[^/]*$")`)
+	}
+
+	src, err = GenerateConformanceTest(tagexpr.New("vd"), "x", []*gentype.Struct{user})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(src), `{"User", func() interface{ Validate() error } { return new(User) }},`)
+		assert.Contains(t, string(src), `{"Item", func() interface{ Validate() error } { return new(Item) }},`)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exprtree exposes the parsed expressions and the tags of tagexpr to the other packages of the module,
+// such as the code generator and the static check, without adding them to the public API.
+// NOTE:
+//  The functions are set by the init of package tagexpr, so import tagexpr before calling them.
+package exprtree
+
+import "reflect"
+
+// Node the expression node, which is also the serialized node of tagexpr
+type Node struct {
+	Op    string      `json:"op"`
+	Field string      `json:"field,omitempty"` // field of selector
+	Name  string      `json:"name,omitempty"`  // name of selector, function or range variable, format of sprintf and tmpl
+	Value interface{} `json:"value,omitempty"` // nil, bool, float64 or string of literal
+	Not   *bool       `json:"not,omitempty"`   // boolOpposite
+	Neg   *bool       `json:"neg,omitempty"`   // signOpposite
+	Paren bool        `json:"paren,omitempty"`
+	Texts []string    `json:"texts,omitempty"` // texts of tmpl
+	Names []string    `json:"names,omitempty"` // placeholder names of tmpl
+	Left  *Node       `json:"left,omitempty"`
+	Right *Node       `json:"right,omitempty"`
+	Args  []*Node     `json:"args,omitempty"`
+}
+
+// the tag operators returned by ReadTags
+const (
+	TagOmit    = "-"
+	TagOmitNil = "?"
+)
+
+var (
+	// Parse parses the expression into the tree, the root is a group node.
+	Parse func(expr string) (*Node, error)
+	// Format returns the normalized source text of the node.
+	Format func(n *Node) string
+	// Pattern returns the regular expression compiled from the pattern literal of the regexp node,
+	// or false if the pattern is not a literal.
+	Pattern func(n *Node) (string, bool)
+	// ReadTags reads and merges the tags of the field by expression name, the same as the *tagexpr.VM @vm.
+	ReadTags func(vm interface{}, field reflect.StructField) (tagOp string, exprs map[string]string, err error)
+	// TagNames returns the struct tag names of the *tagexpr.VM @vm.
+	TagNames func(vm interface{}) []string
+	// Check statically checks the expression, @hasField reports whether the field selector resolves.
+	// The error wraps tagexpr.ErrUnknownFunc, tagexpr.ErrFuncArity or tagexpr.ErrFieldSelector if it is the cause.
+	Check func(expr string, hasField func(fieldSelector string) bool) error
+)
@@ -0,0 +1,85 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gentype
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
+	"github.com/bytedance/go-tagexpr/v2/internal/exprtree"
+)
+
+// Check statically checks the expressions in the tags of the struct type description read by @vm.
+// NOTE:
+//  The expressions are parsed, the field selectors are resolved against the struct fields,
+//  and the functions and their numbers of arguments are checked, see tagexpr.RegFuncWithArity;
+//  The nested struct types are not checked, check them separately;
+//  If there are invalid tags, return TagErrors listing every one of them, otherwise nil.
+func Check(vm *tagexpr.VM, s *Struct) tagexpr.TagErrors {
+	var errs tagexpr.TagErrors
+	resolve := func(fieldSelector string) bool {
+		return hasField(s, fieldSelector)
+	}
+	for _, f := range s.Fields {
+		_, kvs, err := exprtree.ReadTags(vm, reflect.StructField{Name: f.Name, Tag: f.Tag})
+		if err != nil {
+			errs = append(errs, &tagexpr.TagError{Type: s.Name, Field: f.Name, Err: err})
+			continue
+		}
+		exprNames := make([]string, 0, len(kvs))
+		for exprName := range kvs {
+			exprNames = append(exprNames, exprName)
+		}
+		sort.Strings(exprNames)
+		for _, exprName := range exprNames {
+			if err = exprtree.Check(kvs[exprName], resolve); err != nil {
+				err = fmt.Errorf("expression %q: %w", exprName, err)
+				errs = append(errs, &tagexpr.TagError{Type: s.Name, Field: f.Name, Err: err})
+			}
+		}
+	}
+	return errs
+}
+
+// hasField returns whether the field selector resolves to a field of the struct type description.
+func hasField(s *Struct, fieldSelector string) bool {
+	for _, name := range strings.Split(fieldSelector, tagexpr.FieldSeparator) {
+		if s == nil {
+			return false
+		}
+		var field *Field
+		for _, f := range s.Fields {
+			if f.Name == name {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return false
+		}
+		t := field.Type
+		for t != nil && t.Kind == reflect.Ptr {
+			t = t.Elem
+		}
+		s = nil
+		if t != nil {
+			s = t.Struct
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gentype
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	str := &Type{Kind: reflect.String}
+	num := &Type{Kind: reflect.Int}
+	addr := &Struct{Name: "Addr", Fields: []*Field{
+		{Name: "City", Type: str},
+	}}
+	user := &Struct{Name: "User", Fields: []*Field{
+		{Name: "Name", Tag: `vd:"len($)>1 && regexp('^a(b)', $); msg:sprintf('bad %v', (Addr.City)$)"`, Type: str},
+		{Name: "Addr", Tag: `vd:"(Addr.City)$!='' || (Age)$>0"`, Type: &Type{Kind: reflect.Ptr, Elem: &Type{Kind: reflect.Struct, Struct: addr}}},
+		{Name: "Age", Tag: `vd:"$>0"`, Type: num},
+	}}
+	assert.Nil(t, Check(tagexpr.New("vd"), user))
+
+	bad := &Struct{Name: "Bad", Fields: []*Field{
+		{Name: "A", Tag: `vd:"(Nope)$>0"`, Type: num},
+		{Name: "B", Tag: `vd:"(A.X)$>0"`, Type: num},
+		{Name: "C", Tag: `vd:"len($, 1)>0; msg:'a(' + nope($)"`, Type: str},
+		{Name: "D", Tag: `vd:"$>0;$>1"`, Type: num},
+		{Name: "E", Tag: `vd:"(($)"`, Type: num},
+	}}
+	errs := Check(tagexpr.New("vd"), bad)
+	if !assert.Len(t, errs, 6) {
+		return
+	}
+	assert.Equal(t, `Bad.A: expression "@": field selector does not exist: (Nope)$`, errs[0].Error())
+	assert.True(t, errors.Is(errs[0], tagexpr.ErrFieldSelector))
+	assert.Equal(t, `Bad.B: expression "@": field selector does not exist: (A.X)$`, errs[1].Error())
+	assert.Equal(t, `Bad.C: expression "@": wrong number of function arguments: len has 2, want 1`, errs[2].Error())
+	assert.True(t, errors.Is(errs[2], tagexpr.ErrFuncArity))
+	assert.Equal(t, `Bad.C: expression "msg": unknown function: nope`, errs[3].Error())
+	assert.True(t, errors.Is(errs[3], tagexpr.ErrUnknownFunc))
+	assert.Equal(t, "D", errs[4].Field)
+	assert.Equal(t, `Bad.E: expression "@": syntax error: "(($)"`, errs[5].Error())
+
+	tagexpr.RegFuncWithArity("checkTestFunc", 1, -1, func(args ...interface{}) interface{} { return true })
+	s := &Struct{Name: "S", Fields: []*Field{{Name: "X", Tag: `vd:"checkTestFunc()"`, Type: num}}}
+	errs = Check(tagexpr.New("vd"), s)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, `S.X: expression "@": wrong number of function arguments: checkTestFunc has 0, want at least 1`, errs[0].Error())
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gentype describes the struct types for the static check and the code generation,
+// and converts the go/types types into the descriptions.
+package gentype
+
+import (
+	"go/types"
+	"reflect"
+)
+
+// Struct the struct type description for the static check and the code generation, see Check.
+type Struct struct {
+	// Name the type name in the generated package, empty if the type is unnamed or declared in other packages
+	Name   string
+	Fields []*Field
+}
+
+// Field the struct field description, see Struct
+type Field struct {
+	// Name the field name, or the type name of the embedded field
+	Name string
+	Tag  reflect.StructTag
+	Type *Type
+}
+
+// Type the field type description, see Struct
+type Type struct {
+	// Kind the kind of the underlying type
+	Kind reflect.Kind
+	// Elem the element type of the pointer, slice, array and map
+	Elem *Type
+	// Key the key type of the map
+	Key *Type
+	// Struct the struct description if Kind is reflect.Struct
+	Struct *Struct
+}
+
+// Converter the converter of the types of the package
+type Converter struct {
+	pkg  *types.Package
+	seen map[types.Type]*Struct
+}
+
+// NewConverter creates a converter, the named struct types of @pkg keep their names.
+func NewConverter(pkg *types.Package) *Converter {
+	return &Converter{pkg: pkg, seen: make(map[types.Type]*Struct)}
+}
+
+var basicKinds = map[types.BasicKind]reflect.Kind{
+	types.Bool:          reflect.Bool,
+	types.Int:           reflect.Int,
+	types.Int8:          reflect.Int8,
+	types.Int16:         reflect.Int16,
+	types.Int32:         reflect.Int32,
+	types.Int64:         reflect.Int64,
+	types.Uint:          reflect.Uint,
+	types.Uint8:         reflect.Uint8,
+	types.Uint16:        reflect.Uint16,
+	types.Uint32:        reflect.Uint32,
+	types.Uint64:        reflect.Uint64,
+	types.Uintptr:       reflect.Uintptr,
+	types.Float32:       reflect.Float32,
+	types.Float64:       reflect.Float64,
+	types.Complex64:     reflect.Complex64,
+	types.Complex128:    reflect.Complex128,
+	types.String:        reflect.String,
+	types.UnsafePointer: reflect.UnsafePointer,
+}
+
+// Type converts the type into the description.
+func (c *Converter) Type(t types.Type) *Type {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return &Type{Kind: basicKinds[u.Kind()]}
+	case *types.Pointer:
+		return &Type{Kind: reflect.Ptr, Elem: c.Type(u.Elem())}
+	case *types.Slice:
+		return &Type{Kind: reflect.Slice, Elem: c.Type(u.Elem())}
+	case *types.Array:
+		return &Type{Kind: reflect.Array, Elem: c.Type(u.Elem())}
+	case *types.Map:
+		return &Type{Kind: reflect.Map, Key: c.Type(u.Key()), Elem: c.Type(u.Elem())}
+	case *types.Chan:
+		return &Type{Kind: reflect.Chan}
+	case *types.Signature:
+		return &Type{Kind: reflect.Func}
+	case *types.Interface:
+		return &Type{Kind: reflect.Interface}
+	case *types.Struct:
+		return &Type{Kind: reflect.Struct, Struct: c.Struct(t)}
+	}
+	return &Type{Kind: reflect.Invalid}
+}
+
+// Struct converts the struct type into the description, returns nil if it is not a struct type.
+func (c *Converter) Struct(t types.Type) *Struct {
+	u, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	if s, ok := c.seen[t]; ok {
+		return s
+	}
+	s := new(Struct)
+	c.seen[t] = s
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() == c.pkg {
+		s.Name = named.Obj().Name()
+	}
+	for i := 0; i < u.NumFields(); i++ {
+		f := u.Field(i)
+		s.Fields = append(s.Fields, &Field{
+			Name: f.Name(),
+			Tag:  reflect.StructTag(u.Tag(i)),
+			Type: c.Type(f.Type()),
+		})
+	}
+	return s
+}
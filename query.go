@@ -0,0 +1,317 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// --------------------------- Query over slices ---------------------------
+//
+// NOTE:
+//  The elements are the structs, struct pointers or interfaces of them,
+//  the nil elements never match and are sorted first;
+//  The expressions are compiled through a bounded LRU cache, see SetQueryCacheSize;
+//  To skip the cache, call the same methods of the CompiledExpr, see Compile.
+
+// DefaultQueryCacheSize the default capacity of the compiled query expression cache
+const DefaultQueryCacheSize = 256
+
+// queryCache the compiled expressions of the query functions, source -> *CompiledExpr
+var queryCache = newLRUCache(DefaultQueryCacheSize, DefaultQueryCacheSize, func(expr string) (interface{}, error) {
+	return Compile(expr)
+})
+
+// SetQueryCacheSize sets the capacity of the LRU cache of the expressions compiled by Filter, Find, Count, Partition and SortBy.
+// NOTE:
+//  If size<=0, DefaultQueryCacheSize is used.
+func SetQueryCacheSize(size int) {
+	queryCache.resize(size)
+}
+
+func compileQuery(expr string) (*CompiledExpr, error) {
+	c, err := queryCache.get(expr)
+	if err != nil {
+		return nil, err
+	}
+	return c.(*CompiledExpr), nil
+}
+
+// Filter returns a new slice of the same type with the elements that match the expression,
+// e.g. Filter(users, "(Age)$ > 30 && hasPrefix((Name)$, 'A')").
+func Filter(slice interface{}, expr string) (interface{}, error) {
+	c, err := compileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return c.Filter(slice)
+}
+
+// Find returns the first element that matches the expression, or nil if not found, see Filter.
+func Find(slice interface{}, expr string) (interface{}, error) {
+	c, err := compileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return c.Find(slice)
+}
+
+// Count returns the number of the elements that match the expression, see Filter.
+func Count(slice interface{}, expr string) (int, error) {
+	c, err := compileQuery(expr)
+	if err != nil {
+		return 0, err
+	}
+	return c.Count(slice)
+}
+
+// Partition splits the slice into two new slices of the same type,
+// the elements that match the expression and the rest, see Filter.
+func Partition(slice interface{}, expr string) (matched, unmatched interface{}, err error) {
+	c, err := compileQuery(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Partition(slice)
+}
+
+// SortBy stably sorts the slice in place by the value of the expression, e.g. SortBy(users, "(Age)$").
+// NOTE:
+//  If desc=true, sort in descending order;
+//  The values are ordered as nil < bool < number < string, the numbers and strings are compared naturally.
+func SortBy(slice interface{}, expr string, desc ...bool) error {
+	c, err := compileQuery(expr)
+	if err != nil {
+		return err
+	}
+	return c.SortBy(slice, desc...)
+}
+
+// Filter returns a new slice of the same type with the elements that match the expression, see tagexpr.Filter.
+func (c *CompiledExpr) Filter(slice interface{}) (interface{}, error) {
+	matched, _, err := c.partition(slice, false)
+	return matched, err
+}
+
+// Find returns the first element that matches the expression, or nil if not found, see tagexpr.Find.
+func (c *CompiledExpr) Find(slice interface{}) (interface{}, error) {
+	var found interface{}
+	err := c.each(slice, func(elem reflect.Value, v interface{}) bool {
+		if FakeBool(v) {
+			found = elem.Interface()
+			return false
+		}
+		return true
+	})
+	return found, err
+}
+
+// Count returns the number of the elements that match the expression, see tagexpr.Count.
+func (c *CompiledExpr) Count(slice interface{}) (int, error) {
+	var n int
+	err := c.each(slice, func(_ reflect.Value, v interface{}) bool {
+		if FakeBool(v) {
+			n++
+		}
+		return true
+	})
+	return n, err
+}
+
+// Partition splits the slice by the expression, see tagexpr.Partition.
+func (c *CompiledExpr) Partition(slice interface{}) (matched, unmatched interface{}, err error) {
+	return c.partition(slice, true)
+}
+
+// SortBy stably sorts the slice in place by the value of the expression, see tagexpr.SortBy.
+func (c *CompiledExpr) SortBy(slice interface{}, desc ...bool) error {
+	sv := reflect.ValueOf(slice)
+	if sv.Kind() != reflect.Slice {
+		return fmt.Errorf("tagexpr: SortBy requires a slice, got %T", slice)
+	}
+	keys := make([]interface{}, 0, sv.Len())
+	err := c.each(slice, func(_ reflect.Value, v interface{}) bool {
+		keys = append(keys, v)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	sort.Stable(&sortByKeys{
+		keys: keys,
+		swap: reflect.Swapper(slice),
+		desc: len(desc) > 0 && desc[0],
+	})
+	return nil
+}
+
+func (c *CompiledExpr) partition(slice interface{}, withUnmatched bool) (matched, unmatched interface{}, err error) {
+	sv := reflect.ValueOf(slice)
+	if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+		return nil, nil, fmt.Errorf("tagexpr: requires a slice or array, got %T", slice)
+	}
+	st := reflect.SliceOf(sv.Type().Elem())
+	mv := reflect.MakeSlice(st, 0, 0)
+	uv := mv
+	err = c.each(slice, func(elem reflect.Value, v interface{}) bool {
+		if FakeBool(v) {
+			mv = reflect.Append(mv, elem)
+		} else if withUnmatched {
+			uv = reflect.Append(uv, elem)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if withUnmatched {
+		unmatched = uv.Interface()
+	}
+	return mv.Interface(), unmatched, nil
+}
+
+// each evaluates the expression against each element of the slice or array in order,
+// the value of the nil element is nil, and fn returns false to stop.
+// NOTE:
+//  The struct VM of the element type is looked up once and reused while the type does not change.
+func (c *CompiledExpr) each(slice interface{}, fn func(elem reflect.Value, v interface{}) bool) error {
+	sv := reflect.ValueOf(slice)
+	if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+		return fmt.Errorf("tagexpr: requires a slice or array, got %T", slice)
+	}
+	var (
+		lastType reflect.Type
+		s        *structVM
+	)
+	for i, n := 0, sv.Len(); i < n; i++ {
+		elem := sv.Index(i)
+		ev := elem
+		for ev.Kind() == reflect.Interface || ev.Kind() == reflect.Ptr {
+			if ev.IsNil() {
+				break
+			}
+			ev = ev.Elem()
+		}
+		if ev.Kind() == reflect.Interface || ev.Kind() == reflect.Ptr {
+			if !fn(elem, nil) {
+				return nil
+			}
+			continue
+		}
+		if !ev.CanAddr() {
+			addr := reflect.New(ev.Type()).Elem()
+			addr.Set(ev)
+			ev = addr
+		}
+		var te *TagExpr
+		if ev.Type() == lastType {
+			te = s.newTagExpr(unsafe.Pointer(ev.UnsafeAddr()), "")
+		} else {
+			var err error
+			te, err = compileVM.Run(ev)
+			if err != nil {
+				return err
+			}
+			if err = c.bind(te.s); err != nil {
+				te.Release()
+				return err
+			}
+			lastType, s = ev.Type(), te.s
+		}
+		v := c.run("", te)
+		te.Release()
+		if !fn(elem, v) {
+			return nil
+		}
+	}
+	return nil
+}
+
+type sortByKeys struct {
+	keys []interface{}
+	swap func(i, j int)
+	desc bool
+}
+
+func (s *sortByKeys) Len() int { return len(s.keys) }
+
+func (s *sortByKeys) Less(i, j int) bool {
+	if s.desc {
+		return compareSortKey(s.keys[j], s.keys[i]) < 0
+	}
+	return compareSortKey(s.keys[i], s.keys[j]) < 0
+}
+
+func (s *sortByKeys) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.swap(i, j)
+}
+
+// compareSortKey compares the values of the expression, see SortBy.
+func compareSortKey(a, b interface{}) int {
+	ra, rb := sortKeyRank(a), sortKeyRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch ra {
+	case 1:
+		ba, bb := a.(bool), b.(bool)
+		switch {
+		case ba == bb:
+			return 0
+		case bb:
+			return -1
+		}
+		return 1
+	case 2:
+		fa, _ := toFloat64(a, false)
+		fb, _ := toFloat64(b, false)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case 3:
+		sa, _ := toString(a, false)
+		sb, _ := toString(b, false)
+		return strings.Compare(sa, sb)
+	}
+	return 0
+}
+
+// sortKeyRank returns 0 for nil and the unsupported values, 1 for bool, 2 for number, 3 for string.
+func sortKeyRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case string:
+		return 3
+	}
+	if _, ok := toFloat64(v, false); ok {
+		return 2
+	}
+	if _, ok := toString(v, false); ok {
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytedance/go-tagexpr/v2"
+)
+
+type queryUser struct {
+	Name string
+	Age  int
+	Addr *compileAddr
+}
+
+type queryAdmin struct {
+	Name  string
+	Age   int8
+	Level int
+}
+
+func TestQuery(t *testing.T) {
+	users := []queryUser{
+		{Name: "Alice", Age: 35},
+		{Name: "Bob", Age: 40, Addr: &compileAddr{City: "SH"}},
+		{Name: "Ann", Age: 20},
+		{Name: "Amy", Age: 31},
+	}
+	const expr = "(Age)$ > 30 && hasPrefix((Name)$, 'A')"
+
+	r, err := tagexpr.Filter(users, expr)
+	assert.NoError(t, err)
+	assert.Equal(t, []queryUser{users[0], users[3]}, r)
+
+	found, err := tagexpr.Find(users, "(Addr.City)$ == 'SH'")
+	assert.NoError(t, err)
+	assert.Equal(t, users[1], found)
+	found, err = tagexpr.Find(users, "(Age)$ > 100")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	n, err := tagexpr.Count(users, expr)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	matched, unmatched, err := tagexpr.Partition(users, expr)
+	assert.NoError(t, err)
+	assert.Equal(t, []queryUser{users[0], users[3]}, matched)
+	assert.Equal(t, []queryUser{users[1], users[2]}, unmatched)
+
+	// slice of pointers with nil elements
+	ptrs := []*queryUser{&users[0], nil, &users[1], &users[2]}
+	r, err = tagexpr.Filter(ptrs, "(Age)$ >= 35")
+	assert.NoError(t, err)
+	assert.Equal(t, []*queryUser{&users[0], &users[1]}, r)
+	assert.NoError(t, tagexpr.SortBy(ptrs, "(Name)$"))
+	assert.Equal(t, []*queryUser{nil, &users[0], &users[2], &users[1]}, ptrs)
+
+	// interfaces of the different struct types
+	mixed := []interface{}{users[0], &queryAdmin{Name: "Root", Age: 50, Level: 9}, nil, users[2]}
+	r, err = tagexpr.Filter(mixed, "(Age)$ > 30")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{mixed[0], mixed[1]}, r)
+	assert.NoError(t, tagexpr.SortBy(mixed, "(Age)$", true))
+	assert.Equal(t, []interface{}{&queryAdmin{Name: "Root", Age: 50, Level: 9}, users[0], users[2], nil}, mixed)
+
+	// stable
+	sorted := append([]queryUser(nil), users...)
+	assert.NoError(t, tagexpr.SortBy(sorted, "(Age)$ > 30"))
+	assert.Equal(t, []queryUser{users[2], users[0], users[1], users[3]}, sorted)
+
+	// arrays are read by value
+	n, err = tagexpr.Count([2]queryUser{users[0], users[1]}, "(Age)$ >= 35")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = tagexpr.Filter(mixed, "(Level)$ > 1")
+	assert.True(t, errors.Is(err, tagexpr.ErrFieldSelector))
+	_, err = tagexpr.Count(users[0], "(Age)$ > 1")
+	assert.EqualError(t, err, "tagexpr: requires a slice or array, got tagexpr_test.queryUser")
+	_, err = tagexpr.Count(users, "$ > 1")
+	assert.Error(t, err)
+}
+
+func BenchmarkFilter(b *testing.B) {
+	users := make([]queryUser, 1000)
+	for i := range users {
+		users[i] = queryUser{Name: "A", Age: i % 100}
+	}
+	c := tagexpr.MustCompile("(Age)$ > 30 && hasPrefix((Name)$, 'A')")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.Filter(users)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"reflect"
+	"strings"
+)
+
+// TagError the error of the invalid struct tag or struct type
+type TagError struct {
+	// Type the struct type name
+	Type string
+	// Field the field name, empty if the error is not of a specific field
+	Field string
+	Err   error
+}
+
+// Error implements error interface.
+func (e *TagError) Error() string {
+	if e.Field == "" {
+		return e.Type + ": " + e.Err.Error()
+	}
+	return e.Type + "." + e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// TagErrors the list of the invalid struct tag errors
+type TagErrors []*TagError
+
+// Error implements error interface.
+func (e TagErrors) Error() string {
+	a := make([]string, len(e))
+	for i, err := range e {
+		a[i] = err.Error()
+	}
+	return strings.Join(a, "\n")
+}
+
+// Register eagerly registers the struct types and their nested struct types.
+// NOTE:
+//  The types can be struct, struct pointer, reflect.Type or reflect.Value;
+//  After registration, the first Run of the types is no longer slower;
+//  If there are invalid tags, return TagErrors listing every one of them across all the types.
+func (vm *VM) Register(types ...interface{}) error {
+	var errs TagErrors
+	vm.rw.Lock()
+	defer vm.rw.Unlock()
+	for _, i := range types {
+		t := typeOf(i)
+		if t == nil {
+			errs = append(errs, &TagError{Type: "<nil>", Err: unsupportNil})
+			continue
+		}
+		_, err := vm.registerStructLocked(t)
+		if err == nil {
+			continue
+		}
+		tagErrs := vm.tagErrors(t, map[reflect.Type]bool{})
+		if len(tagErrs) == 0 {
+			tagErrs = TagErrors{{Type: t.String(), Err: err}}
+		}
+		errs = append(errs, tagErrs...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func typeOf(i interface{}) reflect.Type {
+	switch t := i.(type) {
+	case reflect.Type:
+		return t
+	case reflect.Value:
+		if !t.IsValid() {
+			return nil
+		}
+		return t.Type()
+	}
+	return reflect.TypeOf(i)
+}
+
+// tagErrors returns the errors of every invalid tag of the struct type and its nested struct types.
+func (vm *VM) tagErrors(t reflect.Type, seen map[reflect.Type]bool) TagErrors {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	var errs TagErrors
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		tagOp, err := vm.checkTags(structField)
+		if err != nil {
+			errs = append(errs, &TagError{Type: t.String(), Field: structField.Name, Err: err})
+		}
+		// the elements of the omitted slice, array and map are not registered
+		if tagOp != tagOmit || derefType(structField.Type).Kind() == reflect.Struct {
+			errs = append(errs, vm.nestedTagErrors(structField.Type, seen)...)
+		}
+	}
+	return errs
+}
+
+// nestedTagErrors returns the tag errors of the struct types nested in the field type.
+func (vm *VM) nestedTagErrors(t reflect.Type, seen map[reflect.Type]bool) TagErrors {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return vm.nestedTagErrors(t.Elem(), seen)
+	case reflect.Map:
+		return append(vm.nestedTagErrors(t.Key(), seen), vm.nestedTagErrors(t.Elem(), seen)...)
+	case reflect.Struct:
+		return vm.tagErrors(t, seen)
+	}
+	return nil
+}
+
+// checkTags checks the syntax and the conflicts of the field tags.
+func (vm *VM) checkTags(structField reflect.StructField) (tagOp string, err error) {
+	tagOp, kvs, err := vm.readTags(structField)
+	if err != nil {
+		return "", err
+	}
+	for _, exprString := range kvs {
+		if _, err = parseExpr(exprString); err != nil {
+			return tagOp, err
+		}
+	}
+	return tagOp, nil
+}
@@ -0,0 +1,284 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules the expressions defined outside struct tags.
+// NOTE:
+//  format: type name -> field selector -> expression name -> expression string;
+//  The type name is like 'pkg.User' or 'github.com/x/pkg.User';
+//  The field selector is relative to the type, such as 'Email' or 'Addr.City';
+//  The default expression name is '@'.
+type Rules map[string]map[string]map[string]string
+
+// RuleError the error of the rule defined outside struct tags
+type RuleError struct {
+	// Source the name of the rule document
+	Source string
+	// Line the line of the expression name in the document, or of the error if it is of the whole document,
+	// 0 if unknown, e.g. the rules added by AddRules
+	Line int
+	// Type, Field and ExprName identify the rule by the type name, field selector and expression name,
+	// empty if the error is of the whole document
+	Type, Field, ExprName string
+	Err                   error
+}
+
+// Error implements error interface.
+func (e *RuleError) Error() string {
+	return e.Location() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// Location returns the source name, the line and the type/field path of the rule,
+// e.g. 'rules.yaml:12: pkg.User.Email@email'.
+func (e *RuleError) Location() string {
+	loc := e.Source
+	if e.Line > 0 {
+		loc += ":" + strconv.Itoa(e.Line)
+	}
+	if e.Type != "" {
+		loc += ": " + e.Type
+		if e.Field != "" {
+			loc += FieldSeparator + e.Field
+			if e.ExprName != "" && e.ExprName != DefaultExprName {
+				loc += ExprNameSeparator + e.ExprName
+			}
+		}
+	}
+	return loc
+}
+
+type fieldRule struct {
+	RuleError  // the location
+	expr       *Expr
+	exprString string
+}
+
+// AddRules adds the expressions defined outside struct tags.
+// NOTE:
+//  source is the name of the rule document, used to locate the errors;
+//  The expressions are merged with the struct tags when the struct type is registered,
+//  the struct tags take precedence over the rules, and the earlier added rules over the later ones,
+//  the conflicts are reported, see SetTagConflictHandler;
+//  It only takes effect on the struct types registered after it is called.
+func (vm *VM) AddRules(source string, rules Rules) error {
+	return vm.addRules(source, rules, nil)
+}
+
+// ruleKey the type name, field selector and expression name of the rule
+type ruleKey [3]string
+
+// addRules adds the rules, @lines are the lines of the expression names in the document.
+func (vm *VM) addRules(source string, rules Rules, lines map[ruleKey]int) error {
+	var a []*fieldRule
+	for _, typeName := range sortedMapKeys(rules) {
+		fields := rules[typeName]
+		for _, fieldSelector := range sortedMapKeys(fields) {
+			exprs := fields[fieldSelector]
+			for _, exprName := range sortedMapKeys(exprs) {
+				r := &fieldRule{
+					RuleError: RuleError{
+						Source:   source,
+						Line:     lines[ruleKey{typeName, fieldSelector, exprName}],
+						Type:     typeName,
+						Field:    fieldSelector,
+						ExprName: exprName,
+					},
+					exprString: exprs[exprName],
+				}
+				var err error
+				r.expr, err = parseExpr(r.exprString)
+				if err != nil {
+					r.Err = err
+					return &r.RuleError
+				}
+				a = append(a, r)
+			}
+		}
+	}
+	vm.rw.Lock()
+	for _, r := range a {
+		vm.addRuleLocked(r)
+	}
+	vm.rw.Unlock()
+	return nil
+}
+
+func (vm *VM) addRuleLocked(r *fieldRule) {
+	if vm.rules == nil {
+		vm.rules = make(map[string][]*fieldRule, 16)
+	}
+	vm.rules[r.Type] = append(vm.rules[r.Type], r)
+}
+
+// AddRulesJSON adds the expressions defined in the JSON document, see Rules and AddRules.
+func (vm *VM) AddRulesJSON(source string, data []byte) error {
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		ruleErr := &RuleError{Source: source, Err: err}
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) {
+			ruleErr.Line = lineAt(data, syntaxErr.Offset)
+		} else if errors.As(err, &typeErr) {
+			ruleErr.Line = lineAt(data, typeErr.Offset)
+		}
+		return ruleErr
+	}
+	lines := make(map[ruleKey]int)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, _ := dec.Token(); t == json.Delim('{') {
+		readJSONRuleLines(dec, data, nil, lines)
+	}
+	return vm.addRules(source, rules, lines)
+}
+
+// readJSONRuleLines reads the lines of the keys of the object whose '{' has been read, @path is the keys of the object.
+func readJSONRuleLines(dec *json.Decoder, data []byte, path []string, lines map[ruleKey]int) {
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return
+		}
+		key, _ := t.(string)
+		line := lineAt(data, dec.InputOffset())
+		if t, err = dec.Token(); err != nil {
+			return
+		}
+		if t == json.Delim('{') {
+			readJSONRuleLines(dec, data, append(path[:len(path):len(path)], key), lines)
+		} else if len(path) == 2 {
+			lines[ruleKey{path[0], path[1], key}] = line
+		}
+	}
+	dec.Token() // '}'
+}
+
+// lineAt returns the line of the byte offset in the document, starting from 1.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// AddRulesYAML adds the expressions defined in the YAML document, see Rules and AddRules.
+// NOTE:
+//  The default expression name '@' must be quoted in YAML.
+func (vm *VM) AddRulesYAML(source string, data []byte) error {
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return &RuleError{Source: source, Err: err}
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return &RuleError{Source: source, Err: err}
+	}
+	lines := make(map[ruleKey]int)
+	if len(doc.Content) > 0 {
+		readYAMLRuleLines(doc.Content[0], nil, lines)
+	}
+	return vm.addRules(source, rules, lines)
+}
+
+// readYAMLRuleLines reads the lines of the keys of the mapping node, @path is the keys of the node.
+func readYAMLRuleLines(n *yaml.Node, path []string, lines map[ruleKey]int) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if len(path) == 2 {
+			lines[ruleKey{path[0], path[1], key.Value}] = key.Line
+		} else {
+			readYAMLRuleLines(value, append(path[:len(path):len(path)], key.Value), lines)
+		}
+	}
+}
+
+// applyRules merges the expressions defined outside struct tags into the struct.
+func (s *structVM) applyRules(structType reflect.Type) error {
+	rules := s.vm.rules[structType.String()]
+	if fullName := structType.PkgPath() + "." + structType.Name(); fullName != structType.String() {
+		rules = append(rules[:len(rules):len(rules)], s.vm.rules[fullName]...)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	origins := make(map[string]string, len(rules)) // expression selector -> rule location
+	for _, r := range rules {
+		f, ok := s.fields[r.Field]
+		if !ok {
+			err := r.RuleError
+			err.Err = ErrFieldSelector
+			return &err
+		}
+		exprSelector := r.Field
+		if r.ExprName != DefaultExprName {
+			exprSelector += ExprNameSeparator + r.ExprName
+		}
+		if had, ok := f.exprs[exprSelector]; ok {
+			if had.String() == r.expr.String() {
+				continue
+			}
+			origin, ok := origins[exprSelector]
+			if !ok {
+				origin = strings.Join(s.vm.tagNames, ",")
+			}
+			err := s.vm.handleTagConflict(&TagConflict{
+				Field:    f.structField,
+				ExprName: r.ExprName,
+				TagNames: [2]string{origin, r.Location()},
+				Values:   [2]string{had.String(), r.expr.String()},
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		origins[exprSelector] = r.Location()
+		f.exprs[exprSelector] = r.expr
+		s.exprs[exprSelector] = r.expr
+		s.exprSelectorList = append(s.exprSelectorList, exprSelector)
+	}
+	return nil
+}
+
+// sortedMapKeys returns the sorted keys of the map with string keys.
+func sortedMapKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
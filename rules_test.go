@@ -0,0 +1,125 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytedance/go-tagexpr/v2"
+)
+
+type ruleAddr struct {
+	City   string
+	Street string
+}
+
+type ruleUser struct {
+	Name  string `te:"len($)>0"`
+	Email string
+	Addr  ruleAddr
+}
+
+func TestRules(t *testing.T) {
+	vm := tagexpr.New("te")
+	err := vm.AddRulesYAML("rules.yaml", []byte(`
+tagexpr_test.ruleUser:
+  Email:
+    "@": regexp('@')
+    msg: "'invalid email'"
+  Addr.City:
+    "@": len($)>0 && (Street)$!=''
+github.com/bytedance/go-tagexpr/v2_test.ruleAddr:
+  Street:
+    "@": len($)>1
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, vm.AddRules("map", tagexpr.Rules{
+		"tagexpr_test.ruleUser": {"Name": {"x": "$=='x'"}},
+	}))
+
+	te := vm.MustRun(&ruleUser{Name: "x", Email: "a@b", Addr: ruleAddr{City: "c", Street: "s"}})
+	assert.Equal(t, true, te.Eval("Name"))
+	assert.Equal(t, true, te.Eval("Name@x"))
+	assert.Equal(t, true, te.Eval("Email"))
+	assert.Equal(t, "invalid email", te.Eval("Email@msg"))
+	assert.Equal(t, true, te.Eval("Addr.City"))
+	assert.Equal(t, false, te.Eval("Addr.Street"))
+	assert.Equal(t, []string{"Addr.City", "Addr.Street"}, te.ExprDeps("Addr.City"))
+
+	// the rules of the nested type only
+	te = vm.MustRun(&ruleAddr{})
+	assert.Equal(t, false, te.Eval("Street"))
+	_, err = te.EvalE("City")
+	assert.Equal(t, tagexpr.ErrExprSelector, err)
+}
+
+func TestRuleErrors(t *testing.T) {
+	vm := tagexpr.New("te")
+	err := vm.AddRulesJSON("rules.json", []byte(`{"tagexpr_test.ruleUser":{"Email":{"@":"(("}}}`))
+	assert.EqualError(t, err, `rules.json:1: tagexpr_test.ruleUser.Email: syntax error: "(("`)
+	err = vm.AddRulesJSON("rules.json", []byte(`{"tagexpr_test.ruleUser":{"Email":"$"}}`))
+	var ruleErr *tagexpr.RuleError
+	assert.True(t, errors.As(err, &ruleErr))
+	assert.Equal(t, "rules.json:1", ruleErr.Location())
+	err = vm.AddRulesYAML("rules.yaml", []byte("a: [b"))
+	assert.True(t, errors.As(err, &ruleErr))
+
+	// the lines of the expression names
+	err = vm.AddRulesJSON("rules.json", []byte(`{
+  "tagexpr_test.ruleUser": {
+    "Name": {"@": "$!=''"},
+    "Email": {
+      "@": "$!=''",
+      "msg": "(("
+    }
+  }
+}`))
+	assert.EqualError(t, err, `rules.json:6: tagexpr_test.ruleUser.Email@msg: syntax error: "(("`)
+	err = vm.AddRulesYAML("rules.yaml", []byte(`
+tagexpr_test.ruleUser:
+  Name:
+    "@": $!=''
+  Email:
+    "@": (($)
+`))
+	assert.EqualError(t, err, `rules.yaml:6: tagexpr_test.ruleUser.Email: syntax error: "(($)"`)
+	err = vm.AddRulesJSON("rules.json", []byte("{\n\"a\": {\"b\": 1}}"))
+	assert.True(t, errors.As(err, &ruleErr))
+	assert.Equal(t, 2, ruleErr.Line)
+
+	assert.NoError(t, vm.AddRulesJSON("rules.json", []byte(`{"tagexpr_test.ruleUser":{"Phone":{"@":"$"}}}`)))
+	_, err = vm.Run(&ruleUser{})
+	assert.EqualError(t, err, "rules.json:1: tagexpr_test.ruleUser.Phone: field selector does not exist")
+	assert.True(t, errors.Is(err, tagexpr.ErrFieldSelector))
+
+	// conflicts
+	vm = tagexpr.New("te")
+	assert.NoError(t, vm.AddRules("a", tagexpr.Rules{"tagexpr_test.ruleUser": {"Name": {"@": "len( $ )>0"}, "Email": {"@": "$!=''"}}}))
+	_, err = vm.Run(&ruleUser{})
+	assert.NoError(t, err)
+	vm = tagexpr.New("te")
+	assert.NoError(t, vm.AddRules("b", tagexpr.Rules{"tagexpr_test.ruleAddr": {"City": {"@": "$!=''"}}}))
+	assert.NoError(t, vm.AddRules("c", tagexpr.Rules{"tagexpr_test.ruleAddr": {"City": {"@": "$==''"}}}))
+	_, err = vm.Run(&ruleAddr{})
+	assert.EqualError(t, err, `tag conflict: expression "@" of field City: b: tagexpr_test.ruleAddr.City:"$ != ''" and c: tagexpr_test.ruleAddr.City:"$ == ''"`)
+
+	vm = tagexpr.New("te").SetTagConflictHandler(func(*tagexpr.TagConflict) error { return nil })
+	assert.NoError(t, vm.AddRules("a", tagexpr.Rules{"tagexpr_test.ruleUser": {"Name": {"@": "len($)>1"}}}))
+	te := vm.MustRun(&ruleUser{Name: "x"})
+	assert.Equal(t, true, te.Eval("Name"))
+}
@@ -0,0 +1,276 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"reflect"
+)
+
+// --------------------------- Composite value comparison ---------------------------
+//
+// Comparison spec of the composite values (array, slice, map, struct and the pointers to them):
+//  1. `==` and `!=` compare the composite values structurally:
+//     - arrays and slices are equal if they have the same length and their elements are equal in order,
+//       a nil slice is equal to an empty one;
+//     - maps are equal if they have the same length and each key maps to equal values in both;
+//     - structs are equal if they have the same type and all their fields are equal;
+//     - a composite value is never equal to a scalar value or nil.
+//  2. The elements are compared by kind: numbers numerically regardless of their Go types,
+//     strings and bools by value, composite values recursively; values of different kinds are not equal.
+//  3. `<` `<=` `>` `>=` compare arrays and slices lexicographically: the first unequal elements decide,
+//     otherwise the shorter one is less. The elements must be numbers, strings, arrays or slices,
+//     and elements at the same index must be of the same kind, otherwise the result is false.
+//  4. Maps and structs are not ordered, the `<` `<=` `>` `>=` result is false.
+//  5. The self-referential values are supported: like reflect.DeepEqual,
+//     a pair of composite values already under comparison is assumed equal.
+
+type valueClass int8
+
+const (
+	classInvalid valueClass = iota
+	classNumber
+	classString
+	classBool
+	classList
+	classMap
+	classStruct
+	classOther
+)
+
+func classOf(v reflect.Value) valueClass {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return classInvalid
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return classNumber
+	case reflect.String:
+		return classString
+	case reflect.Bool:
+		return classBool
+	case reflect.Array, reflect.Slice:
+		return classList
+	case reflect.Map:
+		return classMap
+	case reflect.Struct:
+		return classStruct
+	}
+	return classOther
+}
+
+func isComposite(c valueClass) bool {
+	return c == classList || c == classMap || c == classStruct
+}
+
+// derefElem dereferences the pointers and interfaces.
+func derefElem(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// compositeEqual compares the values structurally if either of them is composite.
+// NOTE:
+//  If neither value is composite, returns ok=false.
+func compositeEqual(v0, v1 interface{}) (equal bool, ok bool) {
+	rv0 := derefElem(reflect.ValueOf(v0))
+	rv1 := derefElem(reflect.ValueOf(v1))
+	c0, c1 := classOf(rv0), classOf(rv1)
+	if !isComposite(c0) && !isComposite(c1) {
+		return false, false
+	}
+	if c0 != c1 {
+		return false, true
+	}
+	return deepEqual(rv0, rv1, make(map[visit]struct{})), true
+}
+
+// visit the pair of composite values under comparison, used to stop at the cycles
+type visit struct {
+	a0, a1 uintptr
+	t0, t1 reflect.Type
+}
+
+// visited records the pair of composite values,
+// and returns true if it is already under comparison.
+// NOTE:
+//  The values not referenced by address (non-addressable arrays and structs, nil slices and maps) are never recorded.
+func visited(v0, v1 reflect.Value, seen map[visit]struct{}) bool {
+	a0, a1 := refAddr(v0), refAddr(v1)
+	if a0 == 0 || a1 == 0 {
+		return false
+	}
+	k := visit{a0: a0, a1: a1, t0: v0.Type(), t1: v1.Type()}
+	if _, ok := seen[k]; ok {
+		return true
+	}
+	seen[k] = struct{}{}
+	return false
+}
+
+// refAddr returns the address referenced by the slice or map, or of the addressable value, otherwise 0.
+func refAddr(v reflect.Value) uintptr {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Pointer()
+	}
+	if v.CanAddr() {
+		return v.UnsafeAddr()
+	}
+	return 0
+}
+
+func deepEqual(v0, v1 reflect.Value, seen map[visit]struct{}) bool {
+	v0, v1 = derefElem(v0), derefElem(v1)
+	c := classOf(v0)
+	if c != classOf(v1) {
+		return false
+	}
+	if isComposite(c) && visited(v0, v1, seen) {
+		return true
+	}
+	switch c {
+	case classInvalid:
+		return true
+	case classNumber:
+		return reflectFloat(v0) == reflectFloat(v1)
+	case classString:
+		return v0.String() == v1.String()
+	case classBool:
+		return v0.Bool() == v1.Bool()
+	case classList:
+		n := v0.Len()
+		if n != v1.Len() {
+			return false
+		}
+		for i := 0; i < n; i++ {
+			if !deepEqual(v0.Index(i), v1.Index(i), seen) {
+				return false
+			}
+		}
+		return true
+	case classMap:
+		if v0.Len() != v1.Len() {
+			return false
+		}
+		keyType := v1.Type().Key()
+		iter := v0.MapRange()
+		for iter.Next() {
+			k := safeConvert(iter.Key(), keyType)
+			if !k.IsValid() {
+				return false
+			}
+			e1 := v1.MapIndex(k)
+			if !e1.IsValid() || !deepEqual(iter.Value(), e1, seen) {
+				return false
+			}
+		}
+		return true
+	case classStruct:
+		if v0.Type() != v1.Type() {
+			return false
+		}
+		for i := v0.NumField() - 1; i >= 0; i-- {
+			if !deepEqual(v0.Field(i), v1.Field(i), seen) {
+				return false
+			}
+		}
+		return true
+	}
+	if v0.Type() != v1.Type() || !v0.Type().Comparable() || !v0.CanInterface() || !v1.CanInterface() {
+		return false
+	}
+	return v0.Interface() == v1.Interface()
+}
+
+// compareLists compares the arrays or slices lexicographically.
+// NOTE:
+//  If either value is not array or slice, or the elements are not ordered, returns ok=false.
+func compareLists(v0, v1 interface{}) (result int, ok bool) {
+	rv0 := derefElem(reflect.ValueOf(v0))
+	rv1 := derefElem(reflect.ValueOf(v1))
+	if classOf(rv0) != classList || classOf(rv1) != classList {
+		return 0, false
+	}
+	return compareValues(rv0, rv1, make(map[visit]struct{}))
+}
+
+func compareValues(v0, v1 reflect.Value, seen map[visit]struct{}) (int, bool) {
+	v0, v1 = derefElem(v0), derefElem(v1)
+	c := classOf(v0)
+	if c != classOf(v1) {
+		return 0, false
+	}
+	if c == classList && visited(v0, v1, seen) {
+		return 0, true
+	}
+	switch c {
+	case classNumber:
+		f0, f1 := reflectFloat(v0), reflectFloat(v1)
+		switch {
+		case f0 < f1:
+			return -1, true
+		case f0 > f1:
+			return 1, true
+		case f0 == f1:
+			return 0, true
+		}
+		return 0, false // NaN
+	case classString:
+		s0, s1 := v0.String(), v1.String()
+		switch {
+		case s0 < s1:
+			return -1, true
+		case s0 > s1:
+			return 1, true
+		}
+		return 0, true
+	case classList:
+		n0, n1 := v0.Len(), v1.Len()
+		for i := 0; i < n0 && i < n1; i++ {
+			r, ok := compareValues(v0.Index(i), v1.Index(i), seen)
+			if !ok {
+				return 0, false
+			}
+			if r != 0 {
+				return r, true
+			}
+		}
+		switch {
+		case n0 < n1:
+			return -1, true
+		case n0 > n1:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func reflectFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	default:
+		return float64(v.Uint())
+	}
+}
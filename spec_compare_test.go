@@ -0,0 +1,142 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"testing"
+
+	"github.com/bytedance/go-tagexpr/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompositeCompare(t *testing.T) {
+	type Item struct {
+		ID   int
+		Tags []string
+	}
+	type Side struct {
+		Tags   []string
+		Nums   []int
+		Floats []float64
+		Map    map[string]int
+		Item   Item
+		PItem  *Item
+		Nest   [][]int
+	}
+	type T struct {
+		Old, New Side
+		A        bool `te:"(Old.Tags)$==(New.Tags)$"`
+		B        bool `te:"(Old.Nums)$==(New.Floats)$"`
+		C        bool `te:"(Old.Map)$==(New.Map)$"`
+		D        bool `te:"(Old.Item)$==(New.PItem)$"`
+		E        bool `te:"(Old.Tags)$!=(New.Tags)$"`
+		F        bool `te:"(Old.Tags)$<(New.Tags)$"`
+		G        bool `te:"(Old.Nest)$>=(New.Nest)$"`
+		H        bool `te:"(Old.Map)$<(New.Map)$"`
+		I        bool `te:"(Old.Tags)$==(Old.Nums)$"`
+		J        bool `te:"(Old.Tags)$=='a'"`
+		K        bool `te:"range((Old.Nums)$, #v*2)==range((New.Floats)$, #v)"`
+	}
+	vm := tagexpr.New("te")
+	r := vm.MustRun(&T{
+		Old: Side{
+			Tags: []string{"a", "b"},
+			Nums: []int{1, 2},
+			Map:  map[string]int{"x": 1},
+			Item: Item{ID: 1, Tags: []string{"t"}},
+			Nest: [][]int{{1, 2}, {3}},
+		},
+		New: Side{
+			Tags:   []string{"a", "b"},
+			Floats: []float64{1, 2},
+			Map:    map[string]int{"x": 1},
+			PItem:  &Item{ID: 1, Tags: []string{"t"}},
+			Nest:   [][]int{{1, 2}},
+		},
+	})
+	assert.Equal(t, true, r.Eval("A"))
+	assert.Equal(t, true, r.Eval("B"))
+	assert.Equal(t, true, r.Eval("C"))
+	assert.Equal(t, true, r.Eval("D"))
+	assert.Equal(t, false, r.Eval("E"))
+	assert.Equal(t, false, r.Eval("F"))
+	assert.Equal(t, true, r.Eval("G"))
+	assert.Equal(t, false, r.Eval("H"))
+	assert.Equal(t, false, r.Eval("I"))
+	assert.Equal(t, false, r.Eval("J"))
+	assert.Equal(t, false, r.Eval("K"))
+
+	r = vm.MustRun(&T{
+		Old: Side{
+			Tags: []string{"a", "b"},
+			Nums: []int{2, 4},
+			Map:  map[string]int{"x": 1},
+			Item: Item{ID: 1},
+		},
+		New: Side{
+			Tags:   []string{"a", "c"},
+			Floats: []float64{4, 8},
+			Map:    map[string]int{"y": 1},
+			PItem:  &Item{ID: 2},
+			Nest:   [][]int{{1}},
+		},
+	})
+	assert.Equal(t, false, r.Eval("A"))
+	assert.Equal(t, false, r.Eval("B"))
+	assert.Equal(t, false, r.Eval("C"))
+	assert.Equal(t, false, r.Eval("D"))
+	assert.Equal(t, true, r.Eval("E"))
+	assert.Equal(t, true, r.Eval("F"))
+	assert.Equal(t, false, r.Eval("G"))
+	assert.Equal(t, true, r.Eval("K"))
+}
+
+func TestCompositeCompareCycle(t *testing.T) {
+	type Node struct {
+		Val  int
+		Next *Node
+	}
+	type List []List
+	type T struct {
+		A, B  *Node
+		L, R  interface{}
+		Eq    bool `te:"(A)$ == (B)$"`
+		Self  bool `te:"(A)$ == (A)$"`
+		Ne    bool `te:"(A)$ != (A.Next)$"`
+		ListE bool `te:"(L)$ == (R)$"`
+		ListL bool `te:"(L)$ <= (R)$"`
+	}
+	a := &Node{Val: 1}
+	a.Next = a
+	b := &Node{Val: 1}
+	b.Next = &Node{Val: 1, Next: b}
+	l := make(List, 1)
+	l[0] = l
+	r := List{nil}
+	r[0] = List{r}
+	vm := tagexpr.New("te")
+	te, err := vm.Run(&T{A: a, B: b, L: l, R: r})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, te.EvalBool("Eq"))
+	assert.True(t, te.EvalBool("Self"))
+	assert.False(t, te.EvalBool("Ne"))
+	assert.True(t, te.EvalBool("ListE"))
+	assert.True(t, te.EvalBool("ListL"))
+
+	b.Next.Val = 2
+	assert.False(t, te.EvalBool("Eq"))
+}
@@ -0,0 +1,185 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// --------------------------- Type conversion function ---------------------------
+//
+// NOTE:
+//  The conversion functions return nil if the input is nil or can not be converted.
+
+func init() {
+	for _, f := range []struct {
+		name  string
+		arity int
+		fn    func(...interface{}) interface{}
+	}{
+		{"int", 1, convInt},
+		{"float", 1, convFloat},
+		{"string", 1, convString},
+		{"bool", 1, convBool},
+		{"parseInt", 2, convParseInt},
+		{"formatFloat", 2, convFormatFloat},
+	} {
+		regBuiltinFunc(f.name, f.arity, f.arity, f.fn)
+	}
+}
+
+// int(x): number, numeric string or bool to Integer, truncated toward zero.
+func convInt(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		return nil
+	}
+	switch v := args[0].(type) {
+	case Integer:
+		return v
+	case bool:
+		if v {
+			return Integer(1)
+		}
+		return Integer(0)
+	case string:
+		s := strings.TrimSpace(v)
+		if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return Integer(i)
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil
+		}
+		return floatToInteger(f)
+	}
+	f, ok := toFloat64(args[0], false)
+	if !ok {
+		return nil
+	}
+	return floatToInteger(f)
+}
+
+func floatToInteger(f float64) interface{} {
+	if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+		return nil
+	}
+	return Integer(f)
+}
+
+// float(x): number, numeric string or bool to float64.
+func convFloat(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		return nil
+	}
+	switch v := args[0].(type) {
+	case bool:
+		if v {
+			return 1.0
+		}
+		return 0.0
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil
+		}
+		return f
+	}
+	f, ok := toFloat64(args[0], false)
+	if !ok {
+		return nil
+	}
+	return f
+}
+
+// string(x): any non-nil value to string, numbers without exponent.
+func convString(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		return nil
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case Integer:
+		return strconv.FormatInt(int64(v), 10)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	s, _ := toString(args[0], true)
+	return s
+}
+
+// bool(x): 'true'/'false'/'1'/'0' etc. strings, numbers (x!=0) or bool to bool.
+func convBool(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		return nil
+	}
+	switch v := args[0].(type) {
+	case bool:
+		return v
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+	f, ok := toFloat64(args[0], false)
+	if !ok {
+		return nil
+	}
+	return f != 0
+}
+
+// parseInt(s, base): string in the base (0 means guessing from the prefix) to Integer.
+func convParseInt(args ...interface{}) interface{} {
+	if len(args) != 2 {
+		return nil
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil
+	}
+	base, ok := toFloat64(args[1], false)
+	if !ok || base != math.Trunc(base) {
+		return nil
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(s), int(base), 64)
+	if err != nil {
+		return nil
+	}
+	return Integer(i)
+}
+
+// formatFloat(x, prec): number to string with prec digits after the decimal point (-1 means the shortest).
+func convFormatFloat(args ...interface{}) interface{} {
+	if len(args) != 2 {
+		return nil
+	}
+	f, ok := toFloat64(args[0], false)
+	if !ok {
+		return nil
+	}
+	prec, ok := toFloat64(args[1], false)
+	if !ok || prec != math.Trunc(prec) || prec < -1 {
+		return nil
+	}
+	return strconv.FormatFloat(f, 'f', int(prec), 64)
+}
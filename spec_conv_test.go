@@ -0,0 +1,82 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvFunc(t *testing.T) {
+	var cases = []struct {
+		expr string
+		val  interface{}
+	}{
+		{expr: "int(3.7)", val: Integer(3)},
+		{expr: "int(-3.7)", val: Integer(-3)},
+		{expr: "-int(3.7)", val: Integer(-3)},
+		{expr: "int(' 42 ')", val: Integer(42)},
+		{expr: "int('0x1f')", val: Integer(31)},
+		{expr: "int('2.5')", val: Integer(2)},
+		{expr: "int(true)", val: Integer(1)},
+		{expr: "int('abc')", val: nil},
+		{expr: "int(nil)", val: nil},
+		{expr: "int(1/0)", val: nil},
+		{expr: "int(3)+1", val: 4.0},
+		{expr: "int(3)==3", val: true},
+		{expr: "(int(3))", val: Integer(3)},
+		{expr: "!int(0)", val: true},
+		{expr: "sprintf('%d', int(3))", val: "3"},
+		{expr: "float('1.5')*2", val: 3.0},
+		{expr: "float(false)", val: 0.0},
+		{expr: "float('x')", val: nil},
+		{expr: "string(3)", val: "3"},
+		{expr: "string(0.1+0.2)", val: "0.30000000000000004"},
+		{expr: "string(1000000000000000000000)", val: "1000000000000000000000"},
+		{expr: "string(int(7))", val: "7"},
+		{expr: "string(true)", val: "true"},
+		{expr: "string(nil)", val: nil},
+		{expr: "bool('true')", val: true},
+		{expr: "bool('0')", val: false},
+		{expr: "bool('yes')", val: nil},
+		{expr: "bool(2)", val: true},
+		{expr: "parseInt('ff', 16)", val: Integer(255)},
+		{expr: "parseInt('0b101', 0)", val: Integer(5)},
+		{expr: "parseInt('12', 1)", val: nil},
+		{expr: "parseInt(12, 10)", val: nil},
+		{expr: "formatFloat(3.14159, 2)", val: "3.14"},
+		{expr: "formatFloat(2, -1)", val: "2"},
+		{expr: "formatFloat('2', 1)", val: nil},
+	}
+	for _, c := range cases {
+		vm, err := parseExpr(c.expr)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		assert.Equal(t, c.val, vm.run("", nil), c.expr)
+	}
+
+	type T struct {
+		A int    `te:"sprintf('%d', int($))"`
+		B string `te:"int($) > (A)$"`
+		C string `te:"x:float($)>=1.5;y:string(float($))"`
+	}
+	r := New("te").MustRun(&T{A: 3, B: "10", C: "1.50"})
+	assert.Equal(t, "3", r.Eval("A"))
+	assert.Equal(t, true, r.Eval("B"))
+	assert.Equal(t, true, r.Eval("C@x"))
+	assert.Equal(t, "1.5", r.Eval("C@y"))
+}
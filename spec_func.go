@@ -18,9 +18,11 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
+	"github.com/henrylee2cn/ameda"
 	"github.com/henrylee2cn/goutil/errors"
 )
 
@@ -28,23 +30,57 @@ import (
 
 var funcList = map[string]func(p *Expr, expr *string) ExprNode{}
 
+// funcFns the functions registered by RegFunc, used to load the serialized expressions
+var funcFns = map[string]regFunc{}
+
+// builtinFuncs the built-in functions added after the custom functions of the same names may have been registered,
+// RegFunc overrides them without force so that the custom functions keep working, see regBuiltinFunc
+var builtinFuncs = map[string]bool{}
+
+// regFunc the registered function and the number of its arguments, arity[1] < 0 means unlimited
+type regFunc struct {
+	fn    func(...interface{}) interface{}
+	arity [2]int
+}
+
 // RegFunc registers function expression.
 // NOTE:
 //  example: len($), regexp("\\d") or regexp("\\d",$);
 //  If @force=true, allow to cover the existed same @funcName;
 //  The go number types always are float64;
-//  The go string types always are string.
+//  The go string types always are string;
+//  The args slice is reused after fn returns, so fn must not retain it;
+//  The number of arguments is not checked, see RegFuncWithArity.
 func RegFunc(funcName string, fn func(...interface{}) interface{}, force ...bool) error {
+	return RegFuncWithArity(funcName, 0, -1, fn, force...)
+}
+
+// RegFuncWithArity registers function expression that takes @minArgs to @maxArgs arguments.
+// NOTE:
+//  If @maxArgs < 0, the number of arguments is unlimited;
+//  The number of arguments is checked by the static check and when loading the serialized expressions;
+//  The others are the same as RegFunc.
+func RegFuncWithArity(funcName string, minArgs, maxArgs int, fn func(...interface{}) interface{}, force ...bool) error {
 	if len(force) == 0 || !force[0] {
 		_, ok := funcList[funcName]
-		if ok {
+		if ok && !builtinFuncs[funcName] {
 			return errors.Errorf("duplicate registration expression function: %s", funcName)
 		}
 	}
 	funcList[funcName] = newFunc(funcName, fn)
+	funcFns[funcName] = regFunc{fn: fn, arity: [2]int{minArgs, maxArgs}}
+	delete(builtinFuncs, funcName)
 	return nil
 }
 
+// regBuiltinFunc registers the built-in function, which the custom function of the same name overrides without force.
+func regBuiltinFunc(funcName string, minArgs, maxArgs int, fn func(...interface{}) interface{}) {
+	if err := RegFuncWithArity(funcName, minArgs, maxArgs, fn, true); err != nil {
+		panic(err)
+	}
+	builtinFuncs[funcName] = true
+}
+
 func (p *Expr) parseFuncSign(funcName string, expr *string) (boolOpposite *bool, signOpposite *bool, args []ExprNode, found bool) {
 	prefix := funcName + "("
 	length := len(funcName)
@@ -89,6 +125,7 @@ func newFunc(funcName string, fn func(...interface{}) interface{}) func(*Expr, *
 			return nil
 		}
 		return &funcExprNode{
+			name:         funcName,
 			fn:           fn,
 			boolOpposite: boolOpposite,
 			signOpposite: signOpposite,
@@ -99,36 +136,54 @@ func newFunc(funcName string, fn func(...interface{}) interface{}) func(*Expr, *
 
 type funcExprNode struct {
 	exprBackground
+	name         string
 	args         []ExprNode
 	fn           func(...interface{}) interface{}
 	boolOpposite *bool
 	signOpposite *bool
 }
 
+// funcArgsPool the argument slices of the function calls, *[]interface{}
+var funcArgsPool = sync.Pool{
+	New: func() interface{} {
+		args := make([]interface{}, 0, 4)
+		return &args
+	},
+}
+
 func (f *funcExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
-	var args []interface{}
-	if n := len(f.args); n > 0 {
-		args = make([]interface{}, n)
-		for k, v := range f.args {
-			args[k] = v.Run(ctx, currField, tagExpr)
-		}
+	if len(f.args) == 0 {
+		return realValue(f.fn(), f.boolOpposite, f.signOpposite)
+	}
+	p := funcArgsPool.Get().(*[]interface{})
+	args := (*p)[:0]
+	for _, v := range f.args {
+		args = append(args, v.Run(ctx, currField, tagExpr))
 	}
-	return realValue(f.fn(args...), f.boolOpposite, f.signOpposite)
+	r := f.fn(args...)
+	for k := range args {
+		args[k] = nil
+	}
+	*p = args[:0]
+	funcArgsPool.Put(p)
+	return realValue(r, f.boolOpposite, f.signOpposite)
 }
 
 // --------------------------- Built-in function ---------------------------
 func init() {
 	funcList["regexp"] = readRegexpFuncExprNode
 	funcList["sprintf"] = readSprintfFuncExprNode
+	funcList["tmpl"] = readTmplFuncExprNode
+	builtinFuncs["tmpl"] = true
 	funcList["range"] = readRangeFuncExprNode
-	err := RegFunc("len", func(args ...interface{}) (n interface{}) {
+	err := RegFuncWithArity("len", 1, 1, func(args ...interface{}) (n interface{}) {
 		if len(args) != 1 {
 			return 0
 		}
 		v := args[0]
 		switch e := v.(type) {
 		case string:
-			return float64(len(e))
+			return boxFloat64(float64(len(e)))
 		case float64, bool, nil:
 			return 0
 		}
@@ -137,19 +192,19 @@ func init() {
 				n = 0
 			}
 		}()
-		return float64(reflect.ValueOf(v).Len())
+		return boxFloat64(float64(reflect.ValueOf(v).Len()))
 	}, true)
 	if err != nil {
 		panic(err)
 	}
-	err = RegFunc("mblen", func(args ...interface{}) (n interface{}) {
+	err = RegFuncWithArity("mblen", 1, 1, func(args ...interface{}) (n interface{}) {
 		if len(args) != 1 {
 			return 0
 		}
 		v := args[0]
 		switch e := v.(type) {
 		case string:
-			return float64(len([]rune(e)))
+			return boxFloat64(float64(utf8.RuneCountInString(e)))
 		case float64, bool, nil:
 			return 0
 		}
@@ -158,89 +213,114 @@ func init() {
 				n = 0
 			}
 		}()
-		return float64(reflect.ValueOf(v).Len())
+		return boxFloat64(float64(reflect.ValueOf(v).Len()))
 	}, true)
 	if err != nil {
 		panic(err)
 	}
-}
-
-type regexpFuncExprNode struct {
-	exprBackground
-	re           *regexp.Regexp
-	boolOpposite bool
-}
-
-func readRegexpFuncExprNode(p *Expr, expr *string) ExprNode {
-	last, boolOpposite, _ := getBoolAndSignOpposite(expr)
-	if !strings.HasPrefix(last, "regexp(") {
-		return nil
+	for funcName, fn := range map[string]func(s, substr string) bool{
+		"hasPrefix": strings.HasPrefix,
+		"hasSuffix": strings.HasSuffix,
+		"contains":  strings.Contains,
+	} {
+		regBuiltinFunc(funcName, 2, 2, newStringPredicate(fn))
 	}
-	*expr = last[6:]
-	lastStr := *expr
-	subExprNode := readPairedSymbol(expr, '(', ')')
-	if subExprNode == nil {
-		return nil
-	}
-	s := readPairedSymbol(trimLeftSpace(subExprNode), '\'', '\'')
-	if s == nil {
-		*expr = lastStr
-		return nil
+	for funcName, fn := range map[string]func(string) string{
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	} {
+		fn := fn
+		regBuiltinFunc(funcName, 1, 1, func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				return nil
+			}
+			s, ok := toString(args[0], false)
+			if !ok {
+				return nil
+			}
+			return fn(s)
+		})
 	}
-	rege, err := regexp.Compile(*s)
-	if err != nil {
-		*expr = lastStr
+	regBuiltinFunc("sum", 1, 2, sum)
+}
+
+// sum(list) or sum(list, 'Field'): the sum of the numbers in the slice, array or map,
+// or the sum of the field or key of the struct or map elements, e.g. sum((Lines)$, 'Amount').
+// NOTE:
+//  The elements that are not numbers are skipped, and the nil list is 0.
+func sum(args ...interface{}) interface{} {
+	if len(args) == 0 || len(args) > 2 {
 		return nil
 	}
-	operand := newGroupExprNode()
-	trimLeftSpace(subExprNode)
-	if strings.HasPrefix(*subExprNode, ",") {
-		*subExprNode = (*subExprNode)[1:]
-		_, err = p.parseExprNode(trimLeftSpace(subExprNode), operand)
-		if err != nil {
-			*expr = lastStr
+	var field string
+	if len(args) == 2 {
+		var ok bool
+		if field, ok = toString(args[1], false); !ok {
 			return nil
 		}
-	} else {
-		var currFieldVal = "$"
-		p.parseExprNode(&currFieldVal, operand)
 	}
-	trimLeftSpace(subExprNode)
-	if *subExprNode != "" {
-		*expr = lastStr
+	v := ameda.DereferenceValue(reflect.ValueOf(args[0]))
+	var elems []reflect.Value
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		elems = make([]reflect.Value, v.Len())
+		for i := range elems {
+			elems[i] = v.Index(i)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elems = append(elems, iter.Value())
+		}
+	case reflect.Invalid:
+		return float64(0)
+	default:
 		return nil
 	}
-	e := &regexpFuncExprNode{
-		re: rege,
-	}
-	if boolOpposite != nil {
-		e.boolOpposite = *boolOpposite
+	var total float64
+	for _, elem := range elems {
+		elem = ameda.DereferenceValue(ameda.DereferenceInterfaceValue(elem))
+		if field != "" {
+			switch elem.Kind() {
+			case reflect.Struct:
+				elem = elem.FieldByName(field)
+			case reflect.Map:
+				if elem.Type().Key().Kind() != reflect.String {
+					continue
+				}
+				elem = elem.MapIndex(reflect.ValueOf(field).Convert(elem.Type().Key()))
+			default:
+				continue
+			}
+			elem = ameda.DereferenceValue(ameda.DereferenceInterfaceValue(elem))
+		}
+		if !elem.IsValid() || !elem.CanInterface() {
+			continue
+		}
+		if f, ok := toFloat64(elem.Interface(), false); ok {
+			total += f
+		}
 	}
-	e.SetRightOperand(operand)
-	return e
+	return total
 }
 
-func (re *regexpFuncExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
-	param := re.rightOperand.Run(ctx, currField, tagExpr)
-	switch v := param.(type) {
-	case string:
-		bol := re.re.MatchString(v)
-		if re.boolOpposite {
-			return !bol
+// newStringPredicate returns the function like hasPrefix(s, prefix),
+// which returns false if any argument is not a string.
+func newStringPredicate(fn func(s, substr string) bool) func(...interface{}) interface{} {
+	return func(args ...interface{}) interface{} {
+		if len(args) != 2 {
+			return false
 		}
-		return bol
-	case float64, bool:
-		return false
-	}
-	v := reflect.ValueOf(param)
-	if v.Kind() == reflect.String {
-		bol := re.re.MatchString(v.String())
-		if re.boolOpposite {
-			return !bol
+		s, ok := toString(args[0], false)
+		if !ok {
+			return false
+		}
+		substr, ok := toString(args[1], false)
+		if !ok {
+			return false
 		}
-		return bol
+		return fn(s, substr)
 	}
-	return false
 }
 
 type sprintfFuncExprNode struct {
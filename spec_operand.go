@@ -31,6 +31,7 @@ type groupExprNode struct {
 	exprBackground
 	boolOpposite *bool
 	signOpposite *bool
+	paren        bool // false if it only wraps an argument or the whole expression
 }
 
 func newGroupExprNode() ExprNode { return &groupExprNode{} }
@@ -42,7 +43,7 @@ func readGroupExprNode(expr *string) (grp ExprNode, subExprNode *string) {
 		return nil, nil
 	}
 	*expr = last
-	e := &groupExprNode{boolOpposite: boolOpposite, signOpposite: signOpposite}
+	e := &groupExprNode{boolOpposite: boolOpposite, signOpposite: signOpposite, paren: true}
 	return e, sptr
 }
 
@@ -218,6 +219,8 @@ func toFloat64(i interface{}, tryParse bool) (float64, bool) {
 		v = float64(t)
 	case uint64:
 		v = float64(t)
+	case Integer:
+		v = float64(t)
 	case nil:
 		ok = false
 	default:
@@ -252,7 +255,7 @@ func realValue(v interface{}, boolOpposite *bool, signOpposite *bool) interface{
 		return bol
 	}
 	switch t := v.(type) {
-	case float64, string:
+	case float64, string, Integer:
 	case float32:
 		v = float64(t)
 	case int:
@@ -293,7 +296,10 @@ func realValue(v interface{}, boolOpposite *bool, signOpposite *bool) interface{
 		}
 	}
 	if signOpposite != nil && *signOpposite {
-		if f, ok := v.(float64); ok {
+		switch f := v.(type) {
+		case float64:
+			v = -f
+		case Integer:
 			v = -f
 		}
 	}
@@ -31,7 +31,7 @@ func (ae *additionExprNode) Run(ctx context.Context, currField string, tagExpr *
 	v1 := ae.rightOperand.Run(ctx, currField, tagExpr)
 	if s0, ok := toFloat64(v0, false); ok {
 		s1, _ := toFloat64(v1, true)
-		return s0 + s1
+		return boxFloat64(s0 + s1)
 	}
 	if s0, ok := toString(v0, false); ok {
 		s1, _ := toString(v1, true)
@@ -47,7 +47,7 @@ func newMultiplicationExprNode() ExprNode { return &multiplicationExprNode{} }
 func (ae *multiplicationExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
 	v0, _ := toFloat64(ae.leftOperand.Run(ctx, currField, tagExpr), true)
 	v1, _ := toFloat64(ae.rightOperand.Run(ctx, currField, tagExpr), true)
-	return v0 * v1
+	return boxFloat64(v0 * v1)
 }
 
 type divisionExprNode struct{ exprBackground }
@@ -60,7 +60,7 @@ func (de *divisionExprNode) Run(ctx context.Context, currField string, tagExpr *
 		return math.NaN()
 	}
 	v0, _ := toFloat64(de.leftOperand.Run(ctx, currField, tagExpr), true)
-	return v0 / v1
+	return boxFloat64(v0 / v1)
 }
 
 type subtractionExprNode struct{ exprBackground }
@@ -70,7 +70,7 @@ func newSubtractionExprNode() ExprNode { return &subtractionExprNode{} }
 func (de *subtractionExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
 	v0, _ := toFloat64(de.leftOperand.Run(ctx, currField, tagExpr), true)
 	v1, _ := toFloat64(de.rightOperand.Run(ctx, currField, tagExpr), true)
-	return v0 - v1
+	return boxFloat64(v0 - v1)
 }
 
 type remainderExprNode struct{ exprBackground }
@@ -79,11 +79,11 @@ func newRemainderExprNode() ExprNode { return &remainderExprNode{} }
 
 func (re *remainderExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
 	v1, _ := toFloat64(re.rightOperand.Run(ctx, currField, tagExpr), true)
-	if v1 == 0 {
+	if int64(v1) == 0 {
 		return math.NaN()
 	}
 	v0, _ := toFloat64(re.leftOperand.Run(ctx, currField, tagExpr), true)
-	return float64(int64(v0) % int64(v1))
+	return boxFloat64(float64(int64(v0) % int64(v1)))
 }
 
 type equalExprNode struct{ exprBackground }
@@ -93,6 +93,9 @@ func newEqualExprNode() ExprNode { return &equalExprNode{} }
 func (ee *equalExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
 	v0 := ee.leftOperand.Run(ctx, currField, tagExpr)
 	v1 := ee.rightOperand.Run(ctx, currField, tagExpr)
+	if r, ok := compositeEqual(v0, v1); ok {
+		return r
+	}
 	if v0 == v1 {
 		return true
 	}
@@ -145,6 +148,9 @@ func (ge *greaterExprNode) Run(ctx context.Context, currField string, tagExpr *T
 		}
 		return false
 	}
+	if r, ok := compareLists(v0, v1); ok {
+		return r > 0
+	}
 	return false
 }
 
@@ -166,6 +172,9 @@ func (ge *greaterEqualExprNode) Run(ctx context.Context, currField string, tagEx
 		}
 		return false
 	}
+	if r, ok := compareLists(v0, v1); ok {
+		return r >= 0
+	}
 	return false
 }
 
@@ -187,6 +196,9 @@ func (le *lessExprNode) Run(ctx context.Context, currField string, tagExpr *TagE
 		}
 		return false
 	}
+	if r, ok := compareLists(v0, v1); ok {
+		return r < 0
+	}
 	return false
 }
 
@@ -208,6 +220,9 @@ func (le *lessEqualExprNode) Run(ctx context.Context, currField string, tagExpr
 		}
 		return false
 	}
+	if r, ok := compareLists(v0, v1); ok {
+		return r <= 0
+	}
 	return false
 }
 
@@ -0,0 +1,189 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// --------------------------- Glob and SQL LIKE pattern ---------------------------
+
+func init() {
+	funcList["glob"] = func(p *Expr, expr *string) ExprNode {
+		return p.readPatternFuncExprNode("glob", globToRegexp, expr)
+	}
+	funcList["like"] = func(p *Expr, expr *string) ExprNode {
+		return p.readPatternFuncExprNode("like", likeToRegexp, expr)
+	}
+	funcList["ilike"] = func(p *Expr, expr *string) ExprNode {
+		return p.readPatternFuncExprNode("ilike", ilikeToRegexp, expr)
+	}
+	for _, funcName := range []string{"glob", "like", "ilike"} {
+		builtinFuncs[funcName] = true
+	}
+}
+
+// globToRegexp converts the glob pattern to an anchored regular expression.
+// NOTE:
+//  The syntax is the same as path.Match, and in addition:
+//  '**' matches any sequence of characters including '/';
+//  '**/' matches zero or more directories.
+func globToRegexp(pattern string) (string, error) {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				b.WriteString(`(?:.*/)?`)
+				i += 3
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				b.WriteString(`.*`)
+				i += 2
+			} else {
+				b.WriteString(`[^/]*`)
+				i++
+			}
+		case '?':
+			b.WriteString(`[^/]`)
+			i++
+		case '[':
+			n, err := writeGlobClass(&b, pattern[i+1:])
+			if err != nil {
+				return "", err
+			}
+			i += n + 1
+		case '\\':
+			if i+1 >= len(pattern) {
+				return "", path.ErrBadPattern
+			}
+			r, size := utf8.DecodeRuneInString(pattern[i+1:])
+			writeRegexpLiteral(&b, r)
+			i += size + 1
+		default:
+			r, size := utf8.DecodeRuneInString(pattern[i:])
+			writeRegexpLiteral(&b, r)
+			i += size
+		}
+	}
+	b.WriteByte('$')
+	return b.String(), nil
+}
+
+// writeGlobClass writes the character class whose content starts after '[',
+// and returns the number of bytes consumed including the closing ']'.
+func writeGlobClass(b *strings.Builder, s string) (int, error) {
+	var i int
+	b.WriteByte('[')
+	if strings.HasPrefix(s, "^") {
+		b.WriteByte('^')
+		i++
+	}
+	readChar := func() (rune, error) {
+		if i >= len(s) {
+			return 0, path.ErrBadPattern
+		}
+		if s[i] == '\\' {
+			i++
+			if i >= len(s) {
+				return 0, path.ErrBadPattern
+			}
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		return r, nil
+	}
+	var count int
+	for {
+		if i >= len(s) {
+			return 0, path.ErrBadPattern
+		}
+		if s[i] == ']' {
+			if count == 0 {
+				return 0, path.ErrBadPattern
+			}
+			b.WriteByte(']')
+			return i + 1, nil
+		}
+		lo, err := readChar()
+		if err != nil {
+			return 0, err
+		}
+		writeRegexpLiteral(b, lo)
+		if i < len(s) && s[i] == '-' {
+			i++
+			hi, err := readChar()
+			if err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, path.ErrBadPattern
+			}
+			b.WriteByte('-')
+			writeRegexpLiteral(b, hi)
+		}
+		count++
+	}
+}
+
+// likeToRegexp converts the SQL LIKE pattern to an anchored regular expression.
+// NOTE:
+//  '%' matches any sequence of characters;
+//  '_' matches any single character;
+//  '\\' escapes the next character.
+func likeToRegexp(pattern string) (string, error) {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch r {
+		case '%':
+			b.WriteString(`.*`)
+		case '_':
+			b.WriteByte('.')
+		case '\\':
+			if i >= len(pattern) {
+				return "", path.ErrBadPattern
+			}
+			r, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			writeRegexpLiteral(&b, r)
+		default:
+			writeRegexpLiteral(&b, r)
+		}
+	}
+	b.WriteByte('$')
+	return b.String(), nil
+}
+
+// ilikeToRegexp is the case-insensitive version of likeToRegexp.
+func ilikeToRegexp(pattern string) (string, error) {
+	s, err := likeToRegexp(pattern)
+	if err != nil {
+		return "", err
+	}
+	return `(?i)` + s, nil
+}
+
+func writeRegexpLiteral(b *strings.Builder, r rune) {
+	if r < utf8.RuneSelf && !('0' <= r && r <= '9' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r == '_') {
+		b.WriteByte('\\')
+	}
+	b.WriteRune(r)
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"path"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	var cases = []struct {
+		pattern, s string
+	}{
+		{"abc", "abc"},
+		{"*", "abc"},
+		{"*c", "abc"},
+		{"a*", "a"},
+		{"a*", "ab/c"},
+		{"a*/b", "abc/b"},
+		{"a*b*c*d*e*/f", "axbxcxdxe/f"},
+		{"a*b?c*x", "abxbbxdbxebxczzx"},
+		{"ab[c]", "abc"},
+		{"ab[b-d]", "abc"},
+		{"ab[^c]", "abc"},
+		{"ab[^b-d]", "abe"},
+		{"a\\*b", "a*b"},
+		{"a?b", "a☺b"},
+		{"a[^a]b", "a☺b"},
+		{"[\\-]", "-"},
+		{"[x\\-]", "z"},
+		{"*.example.com", "api.example.com"},
+		{"*.example.com", "a/b.example.com"},
+		{"a[", "a"},
+		{"[]a]", "]"},
+		{"a\\", "a"},
+		{"[a-", "x"},
+	}
+	for _, c := range cases {
+		expect, expectErr := path.Match(c.pattern, c.s)
+		s, err := globToRegexp(c.pattern)
+		if expectErr != nil {
+			assert.Error(t, err, c.pattern)
+			continue
+		}
+		if !assert.NoError(t, err, c.pattern) {
+			continue
+		}
+		assert.Equal(t, expect, regexp.MustCompile(s).MatchString(c.s), "%s %s", c.pattern, c.s)
+	}
+}
+
+func TestPatternFunc(t *testing.T) {
+	var cases = []struct {
+		expr string
+		val  interface{}
+	}{
+		{expr: "glob('*.example.com', 'api.example.com')", val: true},
+		{expr: "glob('*.example.com', 'example.com')", val: false},
+		{expr: "glob('a/**', 'a/b/c')", val: true},
+		{expr: "glob('a/**/c', 'a/c')", val: true},
+		{expr: "glob('a/**/c', 'a/b/b/c')", val: true},
+		{expr: "glob('a/*/c', 'a/b/b/c')", val: false},
+		{expr: "!glob('a*', 'b')", val: true},
+		{expr: "glob('*', 1)", val: false},
+		{expr: "glob('a'+'*', 'ab')", val: true},
+		{expr: "like('ORD-%', 'ORD-123')", val: true},
+		{expr: "like('ORD-%', 'ord-123')", val: false},
+		{expr: "ilike('ORD-%', 'ord-123')", val: true},
+		{expr: "like('a_c', 'abc')", val: true},
+		{expr: "like('a_c', 'abbc')", val: false},
+		{expr: "like('100\\%', '100%')", val: true},
+		{expr: "like('100\\%', '1000')", val: false},
+		{expr: "like('a.c', 'abc')", val: false},
+		{expr: "like('%', 'a\nb')", val: true},
+	}
+	for _, c := range cases {
+		vm, err := parseExpr(c.expr)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		assert.Equal(t, c.val, vm.run("", nil), c.expr)
+	}
+	for _, expr := range []string{"glob('a[')", "glob('[]')", "glob('a','b','c')"} {
+		_, err := parseExpr(expr)
+		assert.Error(t, err, expr)
+	}
+	_, err := likeToRegexp(`a\`)
+	assert.Error(t, err)
+
+	type T struct {
+		Pattern string
+		Host    string `te:"glob('*.example.com')"`
+		Code    string `te:"like((Pattern)$, $)"`
+	}
+	vm := New("te")
+	r := vm.MustRun(&T{Pattern: "ORD-%", Host: "api.example.com", Code: "ORD-1"})
+	assert.Equal(t, true, r.Eval("Host"))
+	assert.Equal(t, true, r.Eval("Code"))
+	r = vm.MustRun(&T{Pattern: "INV-%", Host: "example.org", Code: "ORD-1"})
+	assert.Equal(t, false, r.Eval("Host"))
+	assert.Equal(t, false, r.Eval("Code"))
+}
@@ -100,43 +100,55 @@ func (e *rangeFuncExprNode) Run(ctx context.Context, currField string, tagExpr *
 	var r []interface{}
 	obj := e.object.Run(ctx, currField, tagExpr)
 	// fmt.Printf("%v\n", obj)
+	e.each(ctx, obj, func(count int, elemCtx context.Context) {
+		if r == nil {
+			r = make([]interface{}, 0, count)
+		}
+		r = append(r, realValue(e.elemExprNode.Run(elemCtx, currField, tagExpr), e.boolOpposite, e.signOpposite))
+	})
+	if r == nil && isRangeable(obj) {
+		r = []interface{}{}
+	}
+	return r
+}
+
+func isRangeable(obj interface{}) bool {
+	switch reflect.ValueOf(obj).Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// each calls fn with the context of each element of the array, slice or map.
+func (e *rangeFuncExprNode) each(ctx context.Context, obj interface{}, fn func(count int, elemCtx context.Context)) {
 	objval := reflect.ValueOf(obj)
 	switch objval.Kind() {
 	case reflect.Array, reflect.Slice:
 		count := objval.Len()
-		r = make([]interface{}, count)
 		ctx = context.WithValue(ctx, rangeLen, count)
 		for i := 0; i < count; i++ {
-			// fmt.Printf("%#v,  (%v)\n", e.elemExprNode, objval.Index(i))
-			r[i] = realValue(e.elemExprNode.Run(
+			fn(count, context.WithValue(
 				context.WithValue(
-					context.WithValue(
-						ctx,
-						rangeKey, i,
-					),
-					rangeValue, objval.Index(i),
+					ctx,
+					rangeKey, i,
 				),
-				currField, tagExpr,
-			), e.boolOpposite, e.signOpposite)
+				rangeValue, objval.Index(i),
+			))
 		}
 	case reflect.Map:
 		keys := objval.MapKeys()
 		count := len(keys)
-		r = make([]interface{}, count)
 		ctx = context.WithValue(ctx, rangeLen, count)
-		for i, key := range keys {
-			r[i] = realValue(e.elemExprNode.Run(
+		for _, key := range keys {
+			fn(count, context.WithValue(
 				context.WithValue(
-					context.WithValue(
-						ctx,
-						rangeKey, key,
-					),
-					rangeValue, objval.MapIndex(key),
+					ctx,
+					rangeKey, key,
 				),
-				currField, tagExpr,
-			), e.boolOpposite, e.signOpposite)
+				rangeValue, objval.MapIndex(key),
+			))
 		}
 	default:
 	}
-	return r
 }
@@ -0,0 +1,235 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// --------------------------- Regular expression ---------------------------
+
+func init() {
+	regBuiltinFunc("regexpFind", 2, 2, func(args ...interface{}) interface{} {
+		if len(args) != 2 {
+			return nil
+		}
+		re, ok := compileRegexpValue(args[0], nil)
+		if !ok {
+			return nil
+		}
+		s, ok := toString(args[1], false)
+		if !ok {
+			return nil
+		}
+		a := re.FindStringSubmatch(s)
+		if a == nil {
+			return nil
+		}
+		r := make([]interface{}, len(a))
+		for i, v := range a {
+			r[i] = v
+		}
+		return r
+	})
+	regBuiltinFunc("regexpReplace", 3, 3, func(args ...interface{}) interface{} {
+		if len(args) != 3 {
+			return nil
+		}
+		re, ok := compileRegexpValue(args[0], nil)
+		if !ok {
+			return nil
+		}
+		s, ok := toString(args[1], false)
+		if !ok {
+			return nil
+		}
+		repl, _ := toString(args[2], true)
+		return re.ReplaceAllString(s, repl)
+	})
+}
+
+type regexpFuncExprNode struct {
+	exprBackground
+	name         string
+	re           *regexp.Regexp // compiled at parse time if the pattern is a string literal
+	pattern      ExprNode
+	translate    func(string) (string, error)
+	boolOpposite bool
+}
+
+// regexp('^\\w*$')
+// regexp('^\\w*$', (X)$)
+// regexp((Pattern)$, (X)$)
+func readRegexpFuncExprNode(p *Expr, expr *string) ExprNode {
+	return p.readPatternFuncExprNode("regexp", nil, expr)
+}
+
+// readPatternFuncExprNode reads a function whose first argument is a pattern,
+// and the optional second argument is the matched value (default is $).
+// NOTE:
+//  If @translate!=nil, it converts the pattern to a regular expression.
+func (p *Expr) readPatternFuncExprNode(funcName string, translate func(string) (string, error), expr *string) ExprNode {
+	boolOpposite, _, args, found := p.parseFuncSign(funcName, expr)
+	if !found {
+		return nil
+	}
+	for _, arg := range args {
+		if arg.RightOperand() == nil {
+			return nil
+		}
+	}
+	e := &regexpFuncExprNode{name: funcName, pattern: args[0], translate: translate}
+	switch len(args) {
+	case 1:
+		operand := newGroupExprNode()
+		var currFieldVal = "$"
+		p.parseExprNode(&currFieldVal, operand)
+		e.SetRightOperand(operand)
+	case 2:
+		e.SetRightOperand(args[1])
+	default:
+		return nil
+	}
+	rege, err := compileLiteralPattern(args[0], translate)
+	if err != nil {
+		return nil
+	}
+	e.re = rege
+	if boolOpposite != nil {
+		e.boolOpposite = *boolOpposite
+	}
+	return e
+}
+
+// compileLiteralPattern compiles the pattern argument if it is a string literal,
+// otherwise returns nil and the pattern is compiled when running.
+func compileLiteralPattern(pattern ExprNode, translate func(string) (string, error)) (*regexp.Regexp, error) {
+	se, ok := pattern.RightOperand().(*stringExprNode)
+	if !ok {
+		return nil, nil
+	}
+	s, ok := se.val.(string)
+	if !ok {
+		return nil, fmt.Errorf("pattern %v is not a string", se.val)
+	}
+	var err error
+	if translate != nil {
+		s, err = translate(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return regexp.Compile(s)
+}
+
+func (re *regexpFuncExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
+	param := re.rightOperand.Run(ctx, currField, tagExpr)
+	var s string
+	switch v := param.(type) {
+	case string:
+		s = v
+	case float64, bool:
+		return false
+	default:
+		v2 := reflect.ValueOf(param)
+		if v2.Kind() != reflect.String {
+			return false
+		}
+		s = v2.String()
+	}
+	rege := re.re
+	if rege == nil {
+		var ok bool
+		rege, ok = compileRegexpValue(re.pattern.Run(ctx, currField, tagExpr), re.translate)
+		if !ok {
+			return false
+		}
+	}
+	bol := rege.MatchString(s)
+	if re.boolOpposite {
+		return !bol
+	}
+	return bol
+}
+
+// matchExprNode is the infix form of regexp(), e.g. $=~'^\\d+$' or $!~(Pattern)$
+type matchExprNode struct {
+	exprBackground
+	opposite bool
+}
+
+func newMatchExprNode() ExprNode { return &matchExprNode{} }
+
+func newNotMatchExprNode() ExprNode { return &matchExprNode{opposite: true} }
+
+func (me *matchExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
+	s, ok := toString(me.leftOperand.Run(ctx, currField, tagExpr), false)
+	if !ok {
+		return false
+	}
+	rege, ok := compileRegexpValue(me.rightOperand.Run(ctx, currField, tagExpr), nil)
+	if !ok {
+		return false
+	}
+	return rege.MatchString(s) != me.opposite
+}
+
+// compileRegexpValue compiles the dynamic pattern value through the regexp cache.
+func compileRegexpValue(pattern interface{}, translate func(string) (string, error)) (*regexp.Regexp, bool) {
+	s, ok := toString(pattern, false)
+	if !ok {
+		return nil, false
+	}
+	if translate != nil {
+		var err error
+		s, err = translate(s)
+		if err != nil {
+			return nil, false
+		}
+	}
+	rege, err := defaultRegexpCache.compile(s)
+	return rege, err == nil
+}
+
+// DefaultRegexpCacheSize the default capacity of the compiled dynamic regexp pattern cache
+const DefaultRegexpCacheSize = 256
+
+var defaultRegexpCache = newRegexpCache(DefaultRegexpCacheSize)
+
+// SetRegexpCacheSize sets the capacity of the LRU cache of the compiled dynamic regexp patterns.
+// NOTE:
+//  The patterns that are string literals are compiled when parsing and are not cached;
+//  If size<=0, DefaultRegexpCacheSize is used.
+func SetRegexpCacheSize(size int) {
+	defaultRegexpCache.resize(size)
+}
+
+// regexpCache the LRU cache of the compiled patterns
+type regexpCache struct{ *lruCache }
+
+func newRegexpCache(size int) *regexpCache {
+	return &regexpCache{newLRUCache(size, DefaultRegexpCacheSize, func(pattern string) (interface{}, error) {
+		return regexp.Compile(pattern)
+	})}
+}
+
+func (c *regexpCache) compile(pattern string) (*regexp.Regexp, error) {
+	v, err := c.get(pattern)
+	re, _ := v.(*regexp.Regexp)
+	return re, err
+}
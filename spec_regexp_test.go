@@ -0,0 +1,90 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamicRegexp(t *testing.T) {
+	type T struct {
+		Pattern string
+		A       string `te:"regexp((Pattern)$)"`
+		B       string `te:"!regexp((Pattern)$, $)"`
+		C       string `te:"$=~(Pattern)$"`
+		D       string `te:"$!~(Pattern)$"`
+		E       string `te:"x:regexpFind('^(\\w+)@(\\w+)$', $);y:regexpReplace('(\\w+)@(\\w+)', $, '$2.$1');z:regexpFind('^\\d+$', $)"`
+		F       int    `te:"$=~'\\d'"`
+	}
+	vm := New("te")
+	r := vm.MustRun(&T{Pattern: `^a\d$`, A: "a1", B: "a1", C: "ab", D: "ab", E: "foo@bar", F: 1})
+	assert.Equal(t, true, r.Eval("A"))
+	assert.Equal(t, false, r.Eval("B"))
+	assert.Equal(t, false, r.Eval("C"))
+	assert.Equal(t, true, r.Eval("D"))
+	assert.Equal(t, []interface{}{"foo@bar", "foo", "bar"}, r.Eval("E@x"))
+	assert.Equal(t, "bar.foo", r.Eval("E@y"))
+	assert.Equal(t, nil, r.Eval("E@z"))
+	assert.Equal(t, false, r.Eval("F"))
+
+	// invalid dynamic pattern never matches
+	r = vm.MustRun(&T{Pattern: `(`, A: "(", B: "(", C: "(", D: "("})
+	assert.Equal(t, false, r.Eval("A"))
+	assert.Equal(t, false, r.Eval("B"))
+	assert.Equal(t, false, r.Eval("C"))
+	assert.Equal(t, false, r.Eval("D"))
+}
+
+func TestRegexpCache(t *testing.T) {
+	c := newRegexpCache(2)
+	re1, err := c.compile("a")
+	assert.NoError(t, err)
+	re2, err := c.compile("a")
+	assert.NoError(t, err)
+	assert.True(t, re1 == re2)
+	_, err = c.compile("(")
+	assert.Error(t, err)
+	_, err = c.compile("b")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, c.len())
+	_, ok := c.items["a"]
+	assert.False(t, ok, "least recently used pattern should be evicted")
+	c.resize(1)
+	assert.Equal(t, 1, c.len())
+	_, ok = c.items["b"]
+	assert.True(t, ok)
+}
+
+func BenchmarkDynamicRegexp(b *testing.B) {
+	type T struct {
+		Pattern string
+		A       string `bench:"regexp((Pattern)$)"`
+	}
+	vm := New("bench")
+	patterns := make([]string, 16)
+	for i := range patterns {
+		patterns[i] = `^a\d{` + strconv.Itoa(i+1) + `}$`
+	}
+	t := &T{A: "a1"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.Pattern = patterns[i%len(patterns)]
+		vm.MustRun(t).Eval("A")
+	}
+}
@@ -52,7 +52,10 @@ func (p *Expr) readSelectorExprNode(expr *string) ExprNode {
 	return operand
 }
 
-var selectorRegexp = regexp.MustCompile(`^([\!\+\-]*)(\([ \t]*[A-Za-z_]+[A-Za-z0-9_\.]*[ \t]*\))?(\$)([\)\[\],\+\-\*\/%><\|&!=\^ \t\\]|$)`)
+// oldSelector selects the field value of the old instance, e.g. $old, (X)$old, see VM.RunTransition
+const oldSelector = "$old"
+
+var selectorRegexp = regexp.MustCompile(`^([\!\+\-]*)(\([ \t]*[A-Za-z_]+[A-Za-z0-9_\.]*[ \t]*\))?(\$(?:old)?)([\)\[\],\+\-\*\/%><\|&!=\^ \t\\]|$)`)
 
 func findSelector(expr *string) (field string, name string, subSelector []string, boolOpposite, signOpposite *bool, found bool) {
 	raw := *expr
@@ -99,6 +102,11 @@ func (se *selectorExprNode) Run(ctx context.Context, currField string, tagExpr *
 	if field == "" {
 		field = currField
 	}
-	v := tagExpr.getValue(field, subFields)
+	var v interface{}
+	if se.name == oldSelector {
+		v = tagExpr.getOldValue(field, subFields)
+	} else {
+		v = tagExpr.getValue(field, subFields)
+	}
 	return realValue(v, se.boolOpposite, se.signOpposite)
 }
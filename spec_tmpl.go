@@ -0,0 +1,163 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type tmplExprNode struct {
+	exprBackground
+	format       string
+	texts        []string   // len(texts) == len(placeholders)+1
+	placeholders []ExprNode // *selectorExprNode or *rangeKvExprNode
+	names        []string
+}
+
+var tmplFieldRegexp = regexp.MustCompile(`^[A-Za-z_]+[A-Za-z0-9_\.]*$`)
+
+// tmpl('Field {Name} must be <= {Max}, got {$}')
+// tmpl('{#k}: {#v} of {##}')
+func readTmplFuncExprNode(p *Expr, expr *string) ExprNode {
+	if !strings.HasPrefix(*expr, "tmpl(") {
+		return nil
+	}
+	lastStr := *expr
+	*expr = (*expr)[4:]
+	subExprNode := readPairedSymbol(expr, '(', ')')
+	if subExprNode == nil {
+		*expr = lastStr
+		return nil
+	}
+	format := readPairedSymbol(trimLeftSpace(subExprNode), '\'', '\'')
+	if format == nil || *trimLeftSpace(subExprNode) != "" {
+		*expr = lastStr
+		return nil
+	}
+	e, err := p.parseTmpl(*format)
+	if err != nil {
+		*expr = lastStr
+		return nil
+	}
+	return e
+}
+
+func (p *Expr) parseTmpl(format string) (*tmplExprNode, error) {
+	e := &tmplExprNode{format: format}
+	var text strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch c {
+		case '{':
+			if i+1 < len(format) && format[i+1] == '{' {
+				text.WriteByte('{')
+				i++
+				continue
+			}
+			end := strings.IndexByte(format[i:], '}')
+			if end == -1 {
+				return nil, fmt.Errorf("syntax error: %q unclosed placeholder", format)
+			}
+			name := strings.TrimSpace(format[i+1 : i+end])
+			operand, err := p.readTmplPlaceholder(name)
+			if err != nil {
+				return nil, err
+			}
+			e.texts = append(e.texts, text.String())
+			text.Reset()
+			e.placeholders = append(e.placeholders, operand)
+			e.names = append(e.names, name)
+			i += end
+		case '}':
+			if i+1 < len(format) && format[i+1] == '}' {
+				text.WriteByte('}')
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("syntax error: %q unexpected '}'", format)
+		default:
+			text.WriteByte(c)
+		}
+	}
+	e.texts = append(e.texts, text.String())
+	return e, nil
+}
+
+func (p *Expr) readTmplPlaceholder(name string) (ExprNode, error) {
+	var s string
+	switch {
+	case name == "$":
+		s = "$"
+	case name == "#k", name == "#v", name == "##":
+		s = name
+		if operand := p.readRangeKvExprNode(&s); operand != nil {
+			return operand, nil
+		}
+	case tmplFieldRegexp.MatchString(name):
+		s = "(" + name + ")$"
+	}
+	if s != "" {
+		if operand := p.readSelectorExprNode(&s); operand != nil {
+			return operand, nil
+		}
+	}
+	return nil, fmt.Errorf("syntax error: invalid placeholder {%s}", name)
+}
+
+func (te *tmplExprNode) Run(ctx context.Context, currField string, tagExpr *TagExpr) interface{} {
+	var b strings.Builder
+	for i, text := range te.texts {
+		b.WriteString(text)
+		if i < len(te.placeholders) {
+			fmt.Fprint(&b, te.placeholders[i].Run(ctx, currField, tagExpr))
+		}
+	}
+	return b.String()
+}
+
+// checkTmplFields checks that each field placeholder of the tmpl functions resolves to a field of the struct.
+func (s *structVM) checkTmplFields(f *fieldVM) error {
+	for _, exprSelector := range s.exprSelectorList {
+		expr, ok := f.exprs[exprSelector]
+		if !ok {
+			continue
+		}
+		var err error
+		walkExprNode(expr.expr, func(e ExprNode) bool {
+			tmpl, ok := e.(*tmplExprNode)
+			if !ok {
+				return true
+			}
+			for i, operand := range tmpl.placeholders {
+				se, ok := operand.(*selectorExprNode)
+				if !ok || se.field == "" {
+					continue
+				}
+				if _, ok = s.fields[se.field]; !ok {
+					err = fmt.Errorf("tmpl placeholder {%s} of %s.%s does not resolve to a field", tmpl.names[i], s.name, exprSelector)
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
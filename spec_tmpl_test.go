@@ -0,0 +1,65 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bytedance/go-tagexpr/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTmpl(t *testing.T) {
+	type Limit struct {
+		Max int
+	}
+	type T struct {
+		Name  string
+		Limit Limit
+		Value int      `te:"@:$<=(Limit.Max)$;msg:tmpl('Field {Name} must be <= {Limit.Max}, got {$}')"`
+		Tags  []string `te:"range($, tmpl('{{{#k}/{##}}}={#v}'))"`
+		Raw   string   `te:"tmpl('no placeholder')"`
+	}
+	vm := tagexpr.New("te")
+	r := vm.MustRun(&T{Name: "size", Limit: Limit{Max: 3}, Value: 5, Tags: []string{"a", "b"}})
+	assert.Equal(t, false, r.Eval("Value"))
+	assert.Equal(t, "Field size must be <= 3, got 5", r.Eval("Value@msg"))
+	assert.Equal(t, []interface{}{"{0/2}=a", "{1/2}=b"}, r.Eval("Tags"))
+	assert.Equal(t, "no placeholder", r.Eval("Raw"))
+
+	type Bad struct {
+		A int `te:"tmpl('{B}')"`
+	}
+	_, err := vm.Run(&Bad{})
+	assert.EqualError(t, err, "tmpl placeholder {B} of tagexpr_test.Bad.A does not resolve to a field")
+
+	for _, expr := range []string{
+		"tmpl('{#k}')",
+		"tmpl('{')",
+		"tmpl('}')",
+		"tmpl('{1a}')",
+		"tmpl('{A}', 1)",
+		"tmpl((A)$)",
+	} {
+		typ := reflect.StructOf([]reflect.StructField{{
+			Name: "A",
+			Type: reflect.TypeOf(""),
+			Tag:  reflect.StructTag(`te:"` + expr + `"`),
+		}})
+		_, err = tagexpr.New("te").Run(reflect.New(typ))
+		assert.Error(t, err, expr)
+	}
+}
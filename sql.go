@@ -0,0 +1,548 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/henrylee2cn/ameda"
+)
+
+// ErrSQLUntranslatable the expression has a construct that can not be translated to SQL
+var ErrSQLUntranslatable = errors.New("not translatable to SQL")
+
+// SQLTranslator translates the expressions to the parameterized SQL WHERE predicates.
+// NOTE:
+//  The supported constructs are:
+//  the logical operators && || !, the comparison operators == != > >= < <=,
+//  == nil and != nil (IS NULL, IS NOT NULL), in(), like(), ilike(), hasPrefix(), hasSuffix() and contains();
+//  The other constructs, such as the arithmetic, regexp() and range(), return the error wrapping ErrSQLUntranslatable;
+//  != matches the NULL columns like Filter, e.g. (Name)$ != 'x' is (name <> ? OR name IS NULL);
+//  The other comparisons with the NULL columns are unknown in SQL, so they are false under ! too,
+//  while Filter takes the negation of the false comparison with nil as true.
+type SQLTranslator struct {
+	column      func(fieldSelector string) (string, error)
+	placeholder func(n int) string
+}
+
+// NewSQLTranslator creates a SQL translator.
+// NOTE:
+//  The @column maps the field selector such as 'Name' or 'Addr.City' to the column name, see SQLColumns;
+//  The @column is required, if column==nil, the field selectors return the error wrapping ErrFieldSelector.
+func NewSQLTranslator(column func(fieldSelector string) (string, error)) *SQLTranslator {
+	if column == nil {
+		column = func(fieldSelector string) (string, error) {
+			return "", fmt.Errorf("%w: %s has no column, the column function is nil, see SQLColumns", ErrFieldSelector, fieldSelector)
+		}
+	}
+	return &SQLTranslator{
+		column:      column,
+		placeholder: func(int) string { return "?" },
+	}
+}
+
+// SetPlaceholder customizes the placeholder of the n-th (from 1) argument, the default is '?'.
+// NOTE:
+//  If placeholder==nil, the default is used, see SQLDollarPlaceholder.
+func (t *SQLTranslator) SetPlaceholder(placeholder func(n int) string) *SQLTranslator {
+	if placeholder == nil {
+		placeholder = func(int) string { return "?" }
+	}
+	t.placeholder = placeholder
+	return t
+}
+
+// SQLDollarPlaceholder the numbered placeholder of PostgreSQL, e.g. $1, $2
+func SQLDollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Translate translates the rule string to the SQL predicate and its arguments,
+// e.g. "(Age)$ > 30 && hasPrefix((Name)$, 'A')" to "age > ? AND name LIKE ? ESCAPE '!'", [30, "A%"].
+// NOTE:
+//  The rule string is compiled once and cached, the same as Filter.
+func (t *SQLTranslator) Translate(expr string) (where string, args []interface{}, err error) {
+	c, err := compileQuery(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return t.TranslateExpr(c.Expr)
+}
+
+// TranslateExpr translates the parsed expression to the SQL predicate and its arguments, see Translate.
+// NOTE:
+//  The integral numbers are int64 arguments, the others are float64;
+//  The LIKE patterns use '!' as the escape character.
+func (t *SQLTranslator) TranslateExpr(p *Expr) (where string, args []interface{}, err error) {
+	b := &sqlBuilder{t: t}
+	where, _, err = b.cond(p.expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, b.args, nil
+}
+
+// the precedences of the SQL predicates
+const (
+	sqlPrecOr = 1 + iota
+	sqlPrecAnd
+	sqlPrecNot
+	sqlPrecCmp
+)
+
+var sqlComparisons = map[reflect.Type]string{
+	reflect.TypeOf(&equalExprNode{}):        "=",
+	reflect.TypeOf(&notEqualExprNode{}):     "<>",
+	reflect.TypeOf(&greaterExprNode{}):      ">",
+	reflect.TypeOf(&greaterEqualExprNode{}): ">=",
+	reflect.TypeOf(&lessExprNode{}):         "<",
+	reflect.TypeOf(&lessEqualExprNode{}):    "<=",
+}
+
+type sqlBuilder struct {
+	t    *SQLTranslator
+	args []interface{}
+}
+
+func untranslatable(e ExprNode) error {
+	return fmt.Errorf("%w: %s", ErrSQLUntranslatable, formatExprNode(e))
+}
+
+func isOpposite(boolOpposite *bool) bool {
+	return boolOpposite != nil && *boolOpposite
+}
+
+// sqlWrap wraps the predicate in parentheses if its precedence is lower than the context.
+func sqlWrap(s string, prec, ctxPrec int) string {
+	if prec < ctxPrec {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+func sqlNot(s string, prec int) (string, int, error) {
+	return "NOT " + sqlWrap(s, prec, sqlPrecNot), sqlPrecNot, nil
+}
+
+// cond translates the node in the boolean context.
+func (b *sqlBuilder) cond(e ExprNode) (string, int, error) {
+	switch t := e.(type) {
+	case *groupExprNode:
+		if t.rightOperand == nil {
+			return "", 0, untranslatable(e)
+		}
+		s, prec, err := b.cond(t.rightOperand)
+		if err != nil || !isOpposite(t.boolOpposite) {
+			return s, prec, err
+		}
+		return sqlNot(s, prec)
+	case *andExprNode, *orExprNode:
+		op, prec := " AND ", sqlPrecAnd
+		if _, ok := e.(*orExprNode); ok {
+			op, prec = " OR ", sqlPrecOr
+		}
+		l, lprec, err := b.cond(e.LeftOperand())
+		if err != nil {
+			return "", 0, err
+		}
+		r, rprec, err := b.cond(e.RightOperand())
+		if err != nil {
+			return "", 0, err
+		}
+		return sqlWrap(l, lprec, prec) + op + sqlWrap(r, rprec, prec), prec, nil
+	case *selectorExprNode:
+		s, err := b.column(t)
+		if err != nil {
+			return "", 0, err
+		}
+		if isOpposite(t.boolOpposite) {
+			return sqlNot(s, sqlPrecCmp)
+		}
+		return s, sqlPrecCmp, nil
+	case *boolExprNode:
+		return sqlBool(t.val), sqlPrecCmp, nil
+	case *stringExprNode:
+		if v, ok := t.val.(bool); ok {
+			return sqlBool(v), sqlPrecCmp, nil
+		}
+	case *digitalExprNode:
+		if v, ok := t.val.(bool); ok {
+			return sqlBool(v), sqlPrecCmp, nil
+		}
+	case *nilExprNode:
+		if v, ok := t.val.(bool); ok {
+			return sqlBool(v), sqlPrecCmp, nil
+		}
+	case *funcExprNode:
+		s, err := b.funcCond(t)
+		if err != nil {
+			return "", 0, err
+		}
+		if isOpposite(t.boolOpposite) {
+			return sqlNot(s, sqlPrecCmp)
+		}
+		return s, sqlPrecCmp, nil
+	case *regexpFuncExprNode:
+		s, err := b.likeCond(t)
+		if err != nil {
+			return "", 0, err
+		}
+		if t.boolOpposite {
+			return sqlNot(s, sqlPrecCmp)
+		}
+		return s, sqlPrecCmp, nil
+	default:
+		if op, ok := sqlComparisons[reflect.TypeOf(e)]; ok {
+			s, err := b.compare(op, e.LeftOperand(), e.RightOperand())
+			return s, sqlPrecCmp, err
+		}
+	}
+	return "", 0, untranslatable(e)
+}
+
+func sqlBool(v bool) string {
+	if v {
+		return "1 = 1"
+	}
+	return "1 = 0"
+}
+
+// compare translates the comparison, and the comparison with nil to IS [NOT] NULL.
+func (b *sqlBuilder) compare(op string, left, right ExprNode) (string, error) {
+	if op == "=" || op == "<>" {
+		operand := left
+		if isNilLiteral(left) {
+			operand = right
+		} else if !isNilLiteral(right) {
+			operand = nil
+		}
+		if operand != nil {
+			s, err := b.value(operand)
+			if err != nil {
+				return "", err
+			}
+			if op == "=" {
+				return s + " IS NULL", nil
+			}
+			return s + " IS NOT NULL", nil
+		}
+	}
+	l, err := b.value(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := b.value(right)
+	if err != nil {
+		return "", err
+	}
+	if op == "<>" {
+		// nil != x is true in Filter, but NULL <> x is unknown in SQL
+		switch lNull, rNull := isSQLColumn(left), isSQLColumn(right); {
+		case lNull && rNull:
+			return "(" + l + " <> " + r + " OR (" + l + " IS NULL AND " + r + " IS NOT NULL) OR (" +
+				l + " IS NOT NULL AND " + r + " IS NULL))", nil
+		case lNull:
+			return "(" + l + " <> " + r + " OR " + l + " IS NULL)", nil
+		case rNull:
+			return "(" + l + " <> " + r + " OR " + r + " IS NULL)", nil
+		}
+	}
+	return l + " " + op + " " + r, nil
+}
+
+// isSQLColumn returns whether the value node is translated to a column, which may be NULL.
+func isSQLColumn(e ExprNode) bool {
+	for {
+		g, ok := e.(*groupExprNode)
+		if !ok {
+			break
+		}
+		e = g.rightOperand
+	}
+	_, ok := e.(*selectorExprNode)
+	return ok
+}
+
+// isNilLiteral returns whether the node is the literal nil without the operators.
+func isNilLiteral(e ExprNode) bool {
+	for {
+		g, ok := e.(*groupExprNode)
+		if !ok {
+			break
+		}
+		if g.boolOpposite != nil || g.signOpposite != nil {
+			return false
+		}
+		e = g.rightOperand
+	}
+	n, ok := e.(*nilExprNode)
+	return ok && n.val == nil
+}
+
+// value translates the node in the value context, to a column or a placeholder.
+func (b *sqlBuilder) value(e ExprNode) (string, error) {
+	switch t := e.(type) {
+	case *groupExprNode:
+		if t.boolOpposite != nil || t.rightOperand == nil {
+			break
+		}
+		s, err := b.value(t.rightOperand)
+		if err != nil || !isOpposite(t.signOpposite) {
+			return s, err
+		}
+		return "-" + s, nil
+	case *selectorExprNode:
+		if t.boolOpposite != nil {
+			break
+		}
+		s, err := b.column(t)
+		if err != nil || !isOpposite(t.signOpposite) {
+			return s, err
+		}
+		return "-" + s, nil
+	case *boolExprNode:
+		return b.arg(t.val), nil
+	case *stringExprNode:
+		return b.arg(t.val), nil
+	case *digitalExprNode:
+		if f, ok := t.val.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return b.arg(int64(f)), nil
+		}
+		return b.arg(t.val), nil
+	}
+	return "", untranslatable(e)
+}
+
+// literal returns the string literal of the node.
+func literal(e ExprNode) (string, bool) {
+	for {
+		g, ok := e.(*groupExprNode)
+		if !ok {
+			break
+		}
+		if g.boolOpposite != nil || g.signOpposite != nil {
+			return "", false
+		}
+		e = g.rightOperand
+	}
+	se, ok := e.(*stringExprNode)
+	if !ok {
+		return "", false
+	}
+	s, ok := se.val.(string)
+	return s, ok
+}
+
+func (b *sqlBuilder) column(se *selectorExprNode) (string, error) {
+	if se.field == "" {
+		return "", fmt.Errorf("%w: the current field $ has no column, use (Field)$", ErrSQLUntranslatable)
+	}
+	if len(se.subExprs) > 0 || se.name == oldSelector {
+		return "", untranslatable(se)
+	}
+	return b.t.column(se.field)
+}
+
+func (b *sqlBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return b.t.placeholder(len(b.args))
+}
+
+// funcCond translates in(), hasPrefix(), hasSuffix() and contains().
+func (b *sqlBuilder) funcCond(f *funcExprNode) (string, error) {
+	switch f.name {
+	case "in":
+		if len(f.args) < 2 {
+			break
+		}
+		s, err := b.value(f.args[0])
+		if err != nil {
+			return "", err
+		}
+		list := make([]string, len(f.args)-1)
+		for i, arg := range f.args[1:] {
+			if list[i], err = b.value(arg); err != nil {
+				return "", err
+			}
+		}
+		return s + " IN (" + strings.Join(list, ", ") + ")", nil
+	case "hasPrefix", "hasSuffix", "contains":
+		if len(f.args) != 2 || !builtinFuncs[f.name] {
+			break
+		}
+		s, err := b.value(f.args[0])
+		if err != nil {
+			return "", err
+		}
+		sub, ok := literal(f.args[1])
+		if !ok {
+			return "", fmt.Errorf("%w: the second argument of %s must be a string literal", ErrSQLUntranslatable, f.name)
+		}
+		pattern := sqlLikeEscaper.Replace(sub)
+		switch f.name {
+		case "hasPrefix":
+			pattern += "%"
+		case "hasSuffix":
+			pattern = "%" + pattern
+		default:
+			pattern = "%" + pattern + "%"
+		}
+		return s + " LIKE " + b.arg(pattern) + " ESCAPE '!'", nil
+	}
+	return "", untranslatable(f)
+}
+
+var sqlLikeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// likeCond translates like() and ilike(), whose patterns must be the string literals.
+func (b *sqlBuilder) likeCond(re *regexpFuncExprNode) (string, error) {
+	if re.name != "like" && re.name != "ilike" {
+		return "", untranslatable(re)
+	}
+	pattern, ok := literal(re.pattern)
+	if !ok {
+		return "", fmt.Errorf("%w: the pattern of %s must be a string literal", ErrSQLUntranslatable, re.name)
+	}
+	s, err := b.value(re.rightOperand)
+	if err != nil {
+		return "", err
+	}
+	pattern = likeToSQLPattern(pattern)
+	if re.name == "ilike" {
+		return "LOWER(" + s + ") LIKE LOWER(" + b.arg(pattern) + ") ESCAPE '!'", nil
+	}
+	return s + " LIKE " + b.arg(pattern) + " ESCAPE '!'", nil
+}
+
+// likeToSQLPattern converts the like() pattern escaped by '\' to the one escaped by '!'.
+func likeToSQLPattern(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '!':
+			sb.WriteString("!!")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				if c = pattern[i]; c == '%' || c == '_' || c == '!' {
+					sb.WriteByte('!')
+				}
+			}
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// SQLColumns returns the mapping from the field selectors of the struct to the column names,
+// which is used by NewSQLTranslator.
+// NOTE:
+//  The column is the name in the `db` tag, or the `column` of the `gorm` tag,
+//  or the snake case of the field name if without the tags, e.g. UserID is user_id;
+//  The fields of the embedded structs are selected by their own names and the full selectors, e.g. 'Base.ID' and 'ID';
+//  The fields tagged with `db:"-"` or `gorm:"-"` and the nonexistent fields return the error wrapping ErrFieldSelector.
+func SQLColumns(structOrStructPtrOrType interface{}) func(fieldSelector string) (string, error) {
+	var t reflect.Type
+	switch v := structOrStructPtrOrType.(type) {
+	case reflect.Type:
+		t = v
+	default:
+		t = reflect.TypeOf(v)
+	}
+	t = ameda.DereferenceType(t)
+	columns := make(map[string]string)
+	if t != nil && t.Kind() == reflect.Struct {
+		collectSQLColumns(t, "", columns)
+	}
+	return func(fieldSelector string) (string, error) {
+		if column, ok := columns[fieldSelector]; ok {
+			return column, nil
+		}
+		return "", fmt.Errorf("%w: %s has no column", ErrFieldSelector, fieldSelector)
+	}
+}
+
+func collectSQLColumns(t reflect.Type, prefix string, columns map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		column, ok := sqlColumnName(f)
+		if !ok {
+			continue
+		}
+		if ft := ameda.DereferenceType(f.Type); f.Anonymous && ft.Kind() == reflect.Struct {
+			if _, tagged := f.Tag.Lookup("db"); !tagged {
+				collectSQLColumns(ft, prefix+f.Name+FieldSeparator, columns)
+				if prefix == "" {
+					// the promoted fields
+					collectSQLColumns(ft, "", columns)
+				}
+				continue
+			}
+		}
+		columns[prefix+f.Name] = column
+	}
+}
+
+func sqlColumnName(f reflect.StructField) (string, bool) {
+	if tag, ok := f.Tag.Lookup("db"); ok {
+		name := strings.TrimSpace(strings.SplitN(tag, ",", 2)[0])
+		if name == "-" {
+			return "", false
+		}
+		if name != "" {
+			return name, true
+		}
+	}
+	if tag, ok := f.Tag.Lookup("gorm"); ok {
+		if strings.TrimSpace(tag) == "-" {
+			return "", false
+		}
+		for _, kv := range strings.Split(tag, ";") {
+			kv = strings.TrimSpace(kv)
+			if strings.HasPrefix(strings.ToLower(kv), "column:") {
+				return strings.TrimSpace(kv[len("column:"):]), true
+			}
+		}
+	}
+	return snakeCase(f.Name), true
+}
+
+// snakeCase converts the field name to the snake case, e.g. UserID to user_id, HTTPServer to http_server.
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
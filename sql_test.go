@@ -0,0 +1,102 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytedance/go-tagexpr/v2"
+	_ "github.com/bytedance/go-tagexpr/v2/validator" // registers in()
+)
+
+type sqlBase struct {
+	ID int64
+}
+
+type sqlUser struct {
+	sqlBase
+	UserName  string `db:"name"`
+	Age       int    `gorm:"column:user_age;not null"`
+	Email     *string
+	HTTPProxy string
+	Secret    string `db:"-"`
+	Active    bool
+}
+
+func TestSQLTranslator(t *testing.T) {
+	tr := tagexpr.NewSQLTranslator(tagexpr.SQLColumns(sqlUser{}))
+	cases := []struct {
+		expr  string
+		where string
+		args  []interface{}
+	}{
+		{"(Age)$ > 30 && hasPrefix((UserName)$, 'A')", "user_age > ? AND name LIKE ? ESCAPE '!'", []interface{}{int64(30), "A%"}},
+		{"(Age)$ >= 1.5 || (Age)$ != -2", "user_age >= ? OR (user_age <> ? OR user_age IS NULL)", []interface{}{1.5, int64(-2)}},
+		{"'x' != (UserName)$ && (UserName)$ != (HTTPProxy)$",
+			"(? <> name OR name IS NULL) AND (name <> http_proxy OR (name IS NULL AND http_proxy IS NOT NULL) OR (name IS NOT NULL AND http_proxy IS NULL))", []interface{}{"x"}},
+		{"(Email)$ == nil || nil != (Email)$", "email IS NULL OR email IS NOT NULL", nil},
+		{"((Age)$ < 1 || (Age)$ <= 2) && !((ID)$ == 3 && (sqlBase.ID)$ == 4)", "(user_age < ? OR user_age <= ?) AND NOT (id = ? AND id = ?)", []interface{}{int64(1), int64(2), int64(3), int64(4)}},
+		{"(Active)$ && !(Active)$ && !!(Active)$", "active AND NOT active AND active", nil},
+		{"in((UserName)$, 'a', 'b') && !in((Age)$, 1)", "name IN (?, ?) AND NOT user_age IN (?)", []interface{}{"a", "b", int64(1)}},
+		{"like('a\\_b%', (HTTPProxy)$) && !ilike('100!%')", "", nil},
+		{"like('a\\_b%', (HTTPProxy)$) && !ilike('100!%', (UserName)$)", "http_proxy LIKE ? ESCAPE '!' AND NOT LOWER(name) LIKE LOWER(?) ESCAPE '!'", []interface{}{"a!_b%", "100!!%"}},
+		{"hasSuffix((UserName)$, '50%_') || contains((UserName)$, 'x')", "name LIKE ? ESCAPE '!' OR name LIKE ? ESCAPE '!'", []interface{}{"%50!%!_", "%x%"}},
+		{"(UserName)$ == (HTTPProxy)$ && true || false", "name = http_proxy AND 1 = 1 OR 1 = 0", nil},
+	}
+	for _, c := range cases {
+		where, args, err := tr.Translate(c.expr)
+		if c.where == "" {
+			assert.Error(t, err, c.expr)
+			continue
+		}
+		if assert.NoError(t, err, c.expr) {
+			assert.Equal(t, c.where, where, c.expr)
+			assert.Equal(t, c.args, args, c.expr)
+		}
+	}
+
+	where, args, err := tagexpr.NewSQLTranslator(tagexpr.SQLColumns(sqlUser{})).SetPlaceholder(tagexpr.SQLDollarPlaceholder).
+		Translate("(UserName)$ == 'x' && (Age)$ > 1 && (Age)$ != 2")
+	assert.NoError(t, err)
+	assert.Equal(t, "name = $1 AND user_age > $2 AND (user_age <> $3 OR user_age IS NULL)", where)
+	assert.Equal(t, []interface{}{"x", int64(1), int64(2)}, args)
+
+	// the selectors are not passed through as the columns without the column function
+	_, _, err = tagexpr.NewSQLTranslator(nil).Translate("(Secret)$ == 'x'")
+	assert.True(t, errors.Is(err, tagexpr.ErrFieldSelector))
+	where, _, err = tagexpr.NewSQLTranslator(nil).Translate("true")
+	assert.NoError(t, err)
+	assert.Equal(t, "1 = 1", where)
+
+	for _, expr := range []string{
+		"(Age)$ + 1 > 2",
+		"regexp('^a', (UserName)$)",
+		"(UserName)$ =~ 'a'",
+		"len((UserName)$) > 1",
+		"range((Age)$, #v > 1)",
+		"like((UserName)$, (HTTPProxy)$)",
+		"(Age)$old != (Age)$",
+	} {
+		_, _, err = tr.Translate(expr)
+		assert.True(t, errors.Is(err, tagexpr.ErrSQLUntranslatable), expr)
+	}
+	_, _, err = tr.Translate("(Secret)$ == 'x'")
+	assert.True(t, errors.Is(err, tagexpr.ErrFieldSelector))
+	_, _, err = tr.Translate("(Age)$ + 1 > 2")
+	assert.EqualError(t, err, "not translatable to SQL: (Age)$ + 1")
+}
@@ -0,0 +1,30 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command tagcheck checks the tag expressions of the struct fields.
+//
+// Usage:
+//
+//	tagcheck [-tagnames vd,tagexpr] [-funcs gt,lt] [-binding=false] ./...
+//	go vet -vettool=$(which tagcheck) ./...
+package main
+
+import (
+	"github.com/bytedance/go-tagexpr/v2/tagcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(tagcheck.Analyzer)
+}
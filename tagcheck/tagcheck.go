@@ -0,0 +1,279 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tagcheck defines an Analyzer that checks the tag expressions of the struct fields at build time.
+//
+// It parses the expressions with the parser of tagexpr, resolves the field selectors,
+// checks the functions and their numbers of arguments, and checks the binding tags,
+// reporting the diagnostics at the position of the tag.
+package tagcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	tagexpr "github.com/bytedance/go-tagexpr/v2"
+	"github.com/bytedance/go-tagexpr/v2/internal/gentype"
+	// register the functions of the validator, such as email() and phone()
+	_ "github.com/bytedance/go-tagexpr/v2/validator"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const doc = `check the tag expressions of the struct fields
+
+The expressions in the vd and tagexpr tags are parsed, the field selectors
+such as (Addr.City)$ must resolve to the fields of the struct, and the functions
+must be registered with the right number of arguments. The options of the binding
+tags path, query, header, cookie, form and raw_body must be required or req,
+and the value of the default tag must fit the field type.`
+
+// Analyzer checks the tag expressions of the struct fields.
+var Analyzer = &analysis.Analyzer{
+	Name:     "tagexpr",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var (
+	tagNames     = "vd,tagexpr"
+	checkBinding = true
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&tagNames, "tagnames", tagNames, "comma-separated tag names of the expressions, each one is checked separately")
+	Analyzer.Flags.Var(funcsFlag{}, "funcs", "comma-separated names of the functions registered at runtime by RegFunc")
+	Analyzer.Flags.BoolVar(&checkBinding, "binding", checkBinding, "check the binding tags")
+}
+
+// funcsFlag registers the functions only known at runtime, so that they are not reported as unknown.
+type funcsFlag struct{}
+
+func (funcsFlag) String() string { return "" }
+
+func (funcsFlag) Set(s string) error {
+	for _, funcName := range strings.Split(s, ",") {
+		if funcName = strings.TrimSpace(funcName); funcName != "" {
+			// the duplicate registration error is ignored
+			_ = tagexpr.RegFunc(funcName, func(...interface{}) interface{} { return nil })
+		}
+	}
+	return nil
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	var vms []*tagexpr.VM
+	var vmTagNames []string
+	for _, tagName := range strings.Split(tagNames, ",") {
+		if tagName = strings.TrimSpace(tagName); tagName != "" {
+			vms = append(vms, tagexpr.New(tagName))
+			vmTagNames = append(vmTagNames, tagName)
+		}
+	}
+	c := gentype.NewConverter(pass.Pkg)
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	names := make(map[ast.Expr]string) // struct type -> declared type name
+	ins.Preorder([]ast.Node{(*ast.TypeSpec)(nil), (*ast.StructType)(nil)}, func(n ast.Node) {
+		if ts, ok := n.(*ast.TypeSpec); ok {
+			names[ts.Type] = ts.Name.Name
+			return
+		}
+		st := n.(*ast.StructType)
+		t := pass.TypesInfo.TypeOf(st)
+		if t == nil {
+			return
+		}
+		s := *c.Struct(t)
+		s.Name = names[st]
+		if s.Name == "" {
+			s.Name = "struct"
+		}
+		fields := make(map[string]*ast.Field, len(s.Fields))
+		for _, f := range st.Fields.List {
+			if f.Tag == nil {
+				continue
+			}
+			for _, name := range f.Names {
+				fields[name.Name] = f
+			}
+			if len(f.Names) == 0 {
+				if name := embeddedName(f.Type); name != "" {
+					fields[name] = f
+				}
+			}
+		}
+		for i, vm := range vms {
+			for _, err := range gentype.Check(vm, &s) {
+				if f := fields[err.Field]; f != nil {
+					pass.Reportf(tagPos(f.Tag, vmTagNames[i]), "%s: %v", vmTagNames[i], err)
+				}
+			}
+		}
+		if checkBinding {
+			for i, f := range s.Fields {
+				if af := fields[f.Name]; af != nil {
+					checkBindingTags(pass, af.Tag, s.Name, f, t.Underlying().(*types.Struct).Field(i).Type())
+				}
+			}
+		}
+	})
+	return nil, nil
+}
+
+func embeddedName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e.Name
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			return e.Sel.Name
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		default:
+			return ""
+		}
+	}
+}
+
+// tagPos returns the position of the tag name in the struct tag literal,
+// or the position of the literal if it is not found.
+func tagPos(lit *ast.BasicLit, tagName string) token.Pos {
+	key := tagName + `:"`
+	for i := 0; i+len(key) <= len(lit.Value); i++ {
+		if strings.HasPrefix(lit.Value[i:], key) && (i == 0 || isTagSpace(lit.Value[i-1])) {
+			return lit.Pos() + token.Pos(i)
+		}
+	}
+	return lit.Pos()
+}
+
+func isTagSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '`' || c == '"'
+}
+
+var paramTagNames = []string{"path", "query", "header", "cookie", "form", "raw_body"}
+
+// checkBindingTags checks the options of the parameter tags and the value of the default tag.
+func checkBindingTags(pass *analysis.Pass, lit *ast.BasicLit, typeName string, f *gentype.Field, typ types.Type) {
+	for _, tagName := range paramTagNames {
+		value, ok := f.Tag.Lookup(tagName)
+		if !ok || value == "-" {
+			continue
+		}
+		for _, opt := range strings.Split(value, ",")[1:] {
+			switch opt = strings.TrimSpace(opt); opt {
+			case "required", "req":
+			default:
+				pass.Reportf(tagPos(lit, tagName), "%s: %s.%s: unknown option %q, want required or req", tagName, typeName, f.Name, opt)
+			}
+		}
+	}
+	value, ok := f.Tag.Lookup("default")
+	if !ok {
+		return
+	}
+	if err := checkDefault(value, f.Type, typ); err != nil {
+		pass.Reportf(tagPos(lit, "default"), "default: %s.%s: %v", typeName, f.Name, err)
+	}
+}
+
+// checkDefault checks that the default value can be unmarshaled into the field, like binding does.
+func checkDefault(value string, t *gentype.Type, typ types.Type) error {
+	for t.Kind == reflect.Ptr {
+		t = t.Elem
+		typ = typ.Underlying().(*types.Pointer).Elem()
+	}
+	if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(typ), false, nil, "UnmarshalJSON"); obj != nil {
+		return nil
+	}
+	var err error
+	switch t.Kind {
+	case reflect.String:
+		return nil
+	case reflect.Bool:
+		_, err = strconv.ParseBool(value)
+		if value != "true" && value != "false" {
+			err = fmt.Errorf("invalid syntax")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(value, 10, bitSize(t.Kind))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, err = strconv.ParseUint(value, 10, bitSize(t.Kind))
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(value, bitSize(t.Kind))
+		if err == nil && !json.Valid([]byte(value)) {
+			err = fmt.Errorf("invalid syntax")
+		}
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		value = strings.Replace(value, `"`, `\"`, -1)
+		value = strings.Replace(value, `\'`, "\x00", -1)
+		value = strings.Replace(value, `'`, `"`, -1)
+		value = strings.Replace(value, "\x00", `'`, -1)
+		var v interface{}
+		if err = json.Unmarshal([]byte(value), &v); err != nil {
+			break
+		}
+		switch v.(type) {
+		case []interface{}:
+			if t.Kind != reflect.Slice && t.Kind != reflect.Array {
+				err = fmt.Errorf("want a JSON object")
+			}
+		case map[string]interface{}:
+			if t.Kind != reflect.Map && t.Kind != reflect.Struct {
+				err = fmt.Errorf("want a JSON array")
+			}
+		default:
+			if t.Kind == reflect.Slice || t.Kind == reflect.Array {
+				err = fmt.Errorf("want a JSON array")
+			} else {
+				err = fmt.Errorf("want a JSON object")
+			}
+		}
+	default:
+		if !json.Valid([]byte(value)) {
+			err = fmt.Errorf("invalid JSON")
+		}
+	}
+	if err != nil {
+		if ne, ok := err.(*strconv.NumError); ok {
+			err = ne.Err
+		}
+		return fmt.Errorf("invalid value %q for %s: %v", value, typ, err)
+	}
+	return nil
+}
+
+func bitSize(kind reflect.Kind) int {
+	switch kind {
+	case reflect.Int8, reflect.Uint8:
+		return 8
+	case reflect.Int16, reflect.Uint16:
+		return 16
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 32
+	}
+	return 64
+}
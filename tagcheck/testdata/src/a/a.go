@@ -0,0 +1,37 @@
+package a
+
+import "time"
+
+type Addr struct {
+	City string `vd:"len($)>0"`
+}
+
+type User struct {
+	Name  string  `json:"name" vd:"regexp('^\\w+$')"`
+	Email string  `vd:"email($); msg:'bad email'"`
+	Phone string  `vd:"phone($, 'CN', 1)"`  // want `vd: User.Phone: expression "@": wrong number of function arguments: phone has 3, want 1 to 2`
+	Age   int     `vd:"$>0 && (Nmae)$!=''"` // want `vd: User.Age: expression "@": field selector does not exist: \(Nmae\)\$`
+	Addr  *Addr   `vd:"(Addr.City)$!='x'"`
+	Zip   string  `vd:"(Addr.Zip)$!=''"` // want `vd: User.Zip: expression "@": field selector does not exist: \(Addr.Zip\)\$`
+	Kind  string  `vd:"in($, 'a', 'b')"`
+	Note  string  `tagexpr:"emial($)"` // want `tagexpr: User.Note: expression "@": unknown function: emial`
+	Bad   string  `vd:"$>0;$>1"`       // want `vd: User.Bad: syntax error: .*duplicate expression name`
+	Score float64 `vd:"len()>0"`       // want `vd: User.Score: expression "@": wrong number of function arguments: len has 0, want 1`
+	Level int     `vd:"custom($)"`
+}
+
+type Req struct {
+	ID    int64          `path:"id,required"`
+	Q     string         `query:"q,requird"` // want `query: Req.Q: unknown option "requird", want required or req`
+	N     int            `query:"n" default:"1"`
+	M     *uint8         `default:"300"` // want `default: Req.M: invalid value "300" for uint8: value out of range`
+	F     float32        `default:"1.5"`
+	B     bool           `default:"yes"` // want `default: Req.B: invalid value "yes" for bool: invalid syntax`
+	L     []string       `default:"['a','b']"`
+	Mp    map[string]int `default:"['a']"` // want `default: Req.Mp: invalid value "\[\\"a\\"\]" for map\[string\]int: want a JSON object`
+	T     time.Time      `default:"whatever"`
+	S     string         `default:"anything"`
+	Inner struct {
+		X int `vd:"(Y)$>0"` // want `vd: struct.X: expression "@": field selector does not exist: \(Y\)\$`
+	}
+}
@@ -18,10 +18,12 @@ package tagexpr
 import (
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/henrylee2cn/ameda"
@@ -35,11 +37,19 @@ type (
 	String  = string
 )
 
+// Integer the result type of the int() and parseInt() functions.
+// NOTE:
+//  It is not coerced to Number, so that it can be formatted by sprintf('%d');
+//  The arithmetic operators still return Number.
+type Integer int64
+
 // VM struct tag expression interpreter
 type VM struct {
-	tagName   string
-	structJar map[uintptr]*structVM
-	rw        sync.RWMutex
+	tagNames           []string
+	tagConflictHandler func(*TagConflict) error
+	rules              map[string][]*fieldRule // type name -> rules
+	structJar          map[uintptr]*structVM
+	rw                 sync.RWMutex
 }
 
 // structVM tag expression set of struct
@@ -52,6 +62,8 @@ type structVM struct {
 	exprs                      map[string]*Expr
 	exprSelectorList           []string
 	ifaceTagExprGetters        []func(unsafe.Pointer, string, func(*TagExpr, error) error) error
+	exprDeps                   map[string][]string // expression selector -> field selectors
+	fieldDependents            map[string][]string // field selector -> expression selectors
 	err                        error
 }
 
@@ -76,17 +88,32 @@ type fieldVM struct {
 // New creates a tag expression interpreter that uses tagName as the tag name.
 // NOTE:
 //  If no tagName is specified, no tag expression will be interpreted,
-//  but still can operate the various fields.
+//  but still can operate the various fields;
+//  If multiple tag names are specified, the tags of a field are merged by expression name,
+//  the earlier tag name takes precedence, and the conflicts are reported, see SetTagConflictHandler.
 func New(tagName ...string) *VM {
 	if len(tagName) == 0 {
 		tagName = append(tagName, "")
 	}
 	return &VM{
-		tagName:   tagName[0],
+		tagNames:  tagName,
 		structJar: make(map[uintptr]*structVM, 256),
 	}
 }
 
+// SetTagConflictHandler sets the handler of the conflicts between the tags of the same field.
+// NOTE:
+//  If the handler returns nil, the value of the earlier tag name is used,
+//  otherwise the registration of the struct type fails with the returned error;
+//  If handler==nil, the default is used, which returns the *TagConflict;
+//  It only takes effect on the struct types registered after it is called.
+func (vm *VM) SetTagConflictHandler(handler func(*TagConflict) error) *VM {
+	vm.rw.Lock()
+	vm.tagConflictHandler = handler
+	vm.rw.Unlock()
+	return vm
+}
+
 // MustRun is similar to Run, but panic when error.
 func (vm *VM) MustRun(structOrStructPtrOrReflectValue interface{}) *TagExpr {
 	te, err := vm.Run(structOrStructPtrOrReflectValue)
@@ -148,6 +175,44 @@ func (vm *VM) Run(structPtrOrReflectValue interface{}) (*TagExpr, error) {
 	return s.newTagExpr(ptr, ""), nil
 }
 
+// RunTransition returns the tag expression handler of the new value,
+// whose expressions can read the field values of the old value by $old and (X)$old,
+// e.g. `vd:"$old == $ || ($old == 'draft' && $ == 'published')"`.
+// NOTE:
+//  The old and new values must be of the same struct type, and share the struct VM, see Run;
+//  The nested struct fields, the elements of the same index of the struct slices,
+//  and the values of the same key of the struct maps are paired;
+//  The map keys and the elements of the interface fields are not paired;
+//  If the old value is nil, or a struct has no paired old value, e.g. the old pointer on its path is nil,
+//  $old and (X)$old of the struct are nil.
+func (vm *VM) RunTransition(oldStructPtrOrReflectValue, newStructPtrOrReflectValue interface{}) (*TagExpr, error) {
+	te, err := vm.Run(newStructPtrOrReflectValue)
+	if err != nil {
+		return nil, err
+	}
+	ov, ok := oldStructPtrOrReflectValue.(reflect.Value)
+	if !ok {
+		ov = reflect.ValueOf(oldStructPtrOrReflectValue)
+	}
+	te.transition = true
+	if !ov.IsValid() || (ov.Kind() == reflect.Ptr && ov.IsNil()) {
+		return te, nil
+	}
+	oldTE, err := vm.Run(ov)
+	if err != nil {
+		te.Release()
+		return nil, err
+	}
+	defer oldTE.Release()
+	if oldTE.s != te.s {
+		err = fmt.Errorf("tagexpr: the old value is %s, want %s", oldTE.s.name, te.s.name)
+		te.Release()
+		return nil, err
+	}
+	te.old = oldTE.ptr
+	return te, nil
+}
+
 // RunAny returns the tag expression handler for the @v.
 // NOTE:
 //  The @v can be structured data such as struct, map, slice, array, interface, reflcet.Value, etc.
@@ -284,6 +349,7 @@ func (vm *VM) registerStructLocked(structType reflect.Type) (*structVM, error) {
 	var numField = structType.NumField()
 	var structField reflect.StructField
 	var sub *structVM
+	var fields = make([]*fieldVM, 0, numField)
 	for i := 0; i < numField; i++ {
 		structField = structType.Field(i)
 		field, err := s.newFieldVM(structField)
@@ -291,6 +357,7 @@ func (vm *VM) registerStructLocked(structType reflect.Type) (*structVM, error) {
 			s.err = err
 			return nil, err
 		}
+		fields = append(fields, field)
 		switch field.elemKind {
 		default:
 			field.setUnsupportGetter()
@@ -321,6 +388,17 @@ func (vm *VM) registerStructLocked(structType reflect.Type) (*structVM, error) {
 			}
 		}
 	}
+	if err = s.applyRules(structType); err != nil {
+		s.err = err
+		return nil, err
+	}
+	for _, field := range fields {
+		if err = s.checkTmplFields(field); err != nil {
+			s.err = err
+			return nil, err
+		}
+	}
+	s.buildDeps()
 	return s, nil
 }
 
@@ -417,7 +495,12 @@ func (s *structVM) newFieldVM(structField reflect.StructField) (*fieldVM, error)
 		origin:        s,
 		fieldSelector: structField.Name,
 	}
-	err := f.parseExprs(structField.Tag.Get(s.vm.tagName))
+	tagOp, kvs, err := s.vm.readTags(structField)
+	if err != nil {
+		return nil, err
+	}
+	f.tagOp = tagOp
+	err = f.parseExprs(kvs)
 	if err != nil {
 		return nil, err
 	}
@@ -716,30 +799,82 @@ func (vm *VM) getStructType(t reflect.Type) (reflect.Type, error) {
 	return structType, nil
 }
 
+var tagExprPool = sync.Pool{
+	New: func() interface{} {
+		return new(TagExpr)
+	},
+}
+
 func (s *structVM) newTagExpr(ptr unsafe.Pointer, path string) *TagExpr {
-	te := &TagExpr{
-		s:    s,
-		ptr:  ptr,
-		sub:  make(map[string]*TagExpr, 8),
-		path: strings.TrimPrefix(path, "."),
-	}
+	te := tagExprPool.Get().(*TagExpr)
+	te.s = s
+	te.ptr = ptr
+	te.path = strings.TrimPrefix(path, ".")
 	return te
 }
 
+// Release puts the TagExpr, its checked-out sub TagExprs and expression handlers back to the pool for reuse.
+// NOTE:
+//  It is optional, and reduces the allocations of the hot path;
+//  The TagExpr and the FieldHandler, ExprHandler derived from it must not be used after release;
+//  It must not be called while the TagExpr is still used by other goroutines.
+func (t *TagExpr) Release() {
+	for k, sub := range t.sub {
+		if sub != nil {
+			sub.Release()
+		}
+		delete(t.sub, k)
+	}
+	t.s = nil
+	t.ptr = nil
+	t.path = ""
+	t.dynamic = nil
+	t.old = nil
+	t.transition = false
+	for i := range t.handlers {
+		t.handlers[i] = ExprHandler{}
+	}
+	t.handlers = t.handlers[:0]
+	atomic.StoreUint32(&t.handlersReady, 0)
+	tagExprPool.Put(t)
+}
+
 // TagExpr struct tag expression evaluator
+// NOTE:
+//  It is safe for concurrent use by multiple goroutines,
+//  as long as the struct is not modified at the same time.
 type TagExpr struct {
-	s    *structVM
-	ptr  unsafe.Pointer
-	sub  map[string]*TagExpr
-	path string
+	s       *structVM
+	ptr     unsafe.Pointer
+	sub     map[string]*TagExpr
+	subLock sync.RWMutex
+	path    string
+	dynamic dynamicValue   // the schemaless value, see Expr.EvalMap and Expr.EvalJSON
+	old     unsafe.Pointer // the old instance of the same struct type, see VM.RunTransition
+	// transition whether it is evaluated by VM.RunTransition, $old is nil if there is no paired old instance
+	transition bool
+
+	// the handlers of the expressions, built once by the first Range and reused until Release
+	handlers      []ExprHandler
+	handlersReady uint32
+	handlersLock  sync.Mutex
 }
 
 // EvalFloat evaluates the value of the struct tag expression by the selector expression.
 // NOTE:
-//  If the expression value type is not float64, return 0.
+//  If the expression value type is not float64 or Integer, return 0.
 func (t *TagExpr) EvalFloat(exprSelector string) float64 {
-	r, _ := t.Eval(exprSelector).(float64)
-	return r
+	return evalFloat(t.Eval(exprSelector))
+}
+
+func evalFloat(v interface{}) float64 {
+	switch r := v.(type) {
+	case float64:
+		return r
+	case Integer:
+		return float64(r)
+	}
+	return 0
 }
 
 // EvalString evaluates the value of the struct tag expression by the selector expression.
@@ -784,6 +919,8 @@ func FakeBool(v interface{}) bool {
 		return r != 0
 	case uint64:
 		return r != 0
+	case Integer:
+		return r != 0
 	case string:
 		return r != ""
 	case bool:
@@ -833,6 +970,37 @@ func (t *TagExpr) RangeFields(fn func(*FieldHandler) bool) bool {
 //  format: fieldName, fieldName.exprName, fieldName1.fieldName2.exprName1
 //  result types: float64, string, bool, nil
 func (t *TagExpr) Eval(exprSelector string) interface{} {
+	v, _ := t.EvalE(exprSelector)
+	return v.Interface()
+}
+
+// EvalE evaluates the typed value of the struct tag expression by the selector expression.
+// NOTE:
+//  format: fieldName, fieldName.exprName, fieldName1.fieldName2.exprName1
+//  If the expression selector does not exist, the error is ErrExprSelector;
+//  If the parent field does not exist, the error wraps ErrFieldSelector;
+//  If the parent field is nil and omitted by the '?' tag, the error wraps ErrOmitNil;
+//  Otherwise the error is nil, even if the expression value is nil.
+func (t *TagExpr) EvalE(exprSelector string) (Value, error) {
+	expr, base, targetTagExpr, err := t.lookupExpr(exprSelector)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{v: expr.run(base, targetTagExpr)}, nil
+}
+
+// Explain evaluates the struct tag expression by the selector expression and returns the trace tree.
+// NOTE:
+//  If the expression can not be evaluated, return nil, see EvalE.
+func (t *TagExpr) Explain(exprSelector string) *ExprTrace {
+	expr, base, targetTagExpr, err := t.lookupExpr(exprSelector)
+	if err != nil {
+		return nil
+	}
+	return expr.explain(base, targetTagExpr)
+}
+
+func (t *TagExpr) lookupExpr(exprSelector string) (expr *Expr, base string, targetTagExpr *TagExpr, err error) {
 	expr, ok := t.s.exprs[exprSelector]
 	if !ok {
 		// Compatible with single mode or the expression with the name @
@@ -844,31 +1012,40 @@ func (t *TagExpr) Eval(exprSelector string) interface{} {
 			expr, ok = t.s.exprs[exprSelector]
 		}
 		if !ok {
-			return nil
+			return nil, "", nil, ErrExprSelector
 		}
 	}
 	dir, base := splitFieldSelector(exprSelector)
-	targetTagExpr, err := t.checkout(dir)
+	targetTagExpr, err = t.checkout(dir)
 	if err != nil {
-		return nil
+		return nil, "", nil, fmt.Errorf("%w: %s", err, dir)
 	}
-	return expr.run(base, targetTagExpr)
+	return expr, base, targetTagExpr, nil
 }
 
 // Range loop through each tag expression.
 // When fn returns false, interrupt traversal and return false.
 // NOTE:
-//  eval result types: float64, string, bool, nil
+//  eval result types: float64, string, bool, nil;
+//  The ExprHandlers are built by the first call and shared by the later calls until Release.
 func (t *TagExpr) Range(fn func(*ExprHandler) error) error {
+	return t.rangeFiltered(nil, fn)
+}
+
+// rangeFiltered loop through each tag expression,
+// if fieldSelectors!=nil, only the ones that live on or read the fields.
+func (t *TagExpr) rangeFiltered(fieldSelectors []string, fn func(*ExprHandler) error) error {
 	var err error
-	if list := t.s.exprSelectorList; len(list) > 0 {
-		for _, es := range list {
-			dir, base := splitFieldSelector(es)
-			targetTagExpr, err := t.checkout(dir)
-			if err != nil {
+	if handlers := t.exprHandlers(); len(handlers) > 0 {
+		for i := range handlers {
+			h := &handlers[i]
+			if h.targetExpr == nil {
 				continue
 			}
-			err = fn(newExprHandler(t, targetTagExpr, base, es))
+			if fieldSelectors != nil && !t.s.isDependent(h.selector, fieldSelectors) {
+				continue
+			}
+			err = fn(h)
 			if err != nil {
 				return err
 			}
@@ -879,6 +1056,9 @@ func (t *TagExpr) Range(fn func(*ExprHandler) error) error {
 
 	if list := t.s.fieldsWithIndirectStructVM; len(list) > 0 {
 		for _, f := range list {
+			if fieldSelectors != nil && !matchAnyField(f.fieldSelector, fieldSelectors) {
+				continue
+			}
 			v := f.packElemFrom(ptr)
 			if !v.IsValid() {
 				continue
@@ -892,13 +1072,19 @@ func (t *TagExpr) Range(fn func(*ExprHandler) error) error {
 			if f.elemKind == reflect.Map &&
 				(mapOrSliceElemStructVM != nil || mapKeyStructVM != nil || valueIface || keyIface) {
 				keyPath := f.fieldSelector + "{k}"
+				var oldV reflect.Value
+				if t.old != nil {
+					oldV = f.packElemFrom(t.old)
+				}
 				for _, key := range v.MapKeys() {
 					if mapKeyStructVM != nil {
 						p := unsafe.Pointer(ameda.ValueFrom(derefValue(key)).Pointer())
 						if omitNil && p == nil {
 							continue
 						}
-						err = mapKeyStructVM.newTagExpr(p, keyPath).Range(fn)
+						te := mapKeyStructVM.newTagExpr(p, keyPath)
+						te.transition = t.transition
+						err = te.Range(fn)
 						if err != nil {
 							return err
 						}
@@ -913,7 +1099,21 @@ func (t *TagExpr) Range(fn func(*ExprHandler) error) error {
 						if omitNil && p == nil {
 							continue
 						}
-						err = mapOrSliceElemStructVM.newTagExpr(p, f.fieldSelector+"{v for k="+key.String()+"}").Range(fn)
+						te := mapOrSliceElemStructVM.newTagExpr(p, f.fieldSelector+"{v for k="+key.String()+"}")
+						te.transition = t.transition
+						if oldV.IsValid() {
+							// the old value of the same key
+							if ov := derefValue(oldV.MapIndex(key)); ov.IsValid() {
+								if !ov.CanAddr() {
+									// the map value is not addressable
+									c := reflect.New(ov.Type()).Elem()
+									c.Set(ov)
+									ov = c
+								}
+								te.old = unsafe.Pointer(ov.UnsafeAddr())
+							}
+						}
+						err = te.Range(fn)
 						if err != nil {
 							return err
 						}
@@ -927,13 +1127,25 @@ func (t *TagExpr) Range(fn func(*ExprHandler) error) error {
 
 			} else if mapOrSliceElemStructVM != nil || valueIface {
 				// slice or array
+				var oldV reflect.Value
+				if t.old != nil {
+					oldV = f.packElemFrom(t.old)
+				}
 				for i := v.Len() - 1; i >= 0; i-- {
 					if mapOrSliceElemStructVM != nil {
 						p := unsafe.Pointer(ameda.ValueFrom(derefValue(v.Index(i))).Pointer())
 						if omitNil && p == nil {
 							continue
 						}
-						err = mapOrSliceElemStructVM.newTagExpr(p, f.fieldSelector+"["+strconv.Itoa(i)+"]").Range(fn)
+						te := mapOrSliceElemStructVM.newTagExpr(p, f.fieldSelector+"["+strconv.Itoa(i)+"]")
+						te.transition = t.transition
+						if oldV.IsValid() && i < oldV.Len() {
+							// the old element of the same index
+							if ov := ameda.DereferenceValue(oldV.Index(i)); ov.CanAddr() {
+								te.old = unsafe.Pointer(ov.UnsafeAddr())
+							}
+						}
+						err = te.Range(fn)
 						if err != nil {
 							return err
 						}
@@ -954,6 +1166,10 @@ func (t *TagExpr) Range(fn func(*ExprHandler) error) error {
 				if err != nil {
 					return err
 				}
+				if fieldSelectors != nil && !matchAnyField(te.path, fieldSelectors) {
+					return nil
+				}
+				te.transition = t.transition
 				return te.Range(fn)
 			})
 			if err != nil {
@@ -964,46 +1180,120 @@ func (t *TagExpr) Range(fn func(*ExprHandler) error) error {
 	return nil
 }
 
+// exprHandlers returns the handlers of the expressions in the order of t.s.exprSelectorList,
+// the targetExpr of the handler is nil if the struct is not checked out, e.g. omitted by the '?' tag.
+// NOTE:
+//  The handlers are built once, reusing the memory of the released TagExpr, and are read-only after that.
+func (t *TagExpr) exprHandlers() []ExprHandler {
+	if atomic.LoadUint32(&t.handlersReady) == 0 {
+		t.handlersLock.Lock()
+		if atomic.LoadUint32(&t.handlersReady) == 0 {
+			list := t.s.exprSelectorList
+			handlers := t.handlers[:0]
+			for _, es := range list {
+				dir, base := splitFieldSelector(es)
+				targetTagExpr, err := t.checkout(dir)
+				if err != nil {
+					targetTagExpr = nil
+				}
+				handlers = append(handlers, ExprHandler{
+					base:       base,
+					selector:   es,
+					expr:       t,
+					targetExpr: targetTagExpr,
+				})
+			}
+			t.handlers = handlers
+			atomic.StoreUint32(&t.handlersReady, 1)
+		}
+		t.handlersLock.Unlock()
+	}
+	return t.handlers
+}
+
 func (t *TagExpr) subRange(omitNil bool, path string, value reflect.Value, fn func(*ExprHandler) error) error {
 	return t.s.vm.subRunAll(omitNil, path, value, func(te *TagExpr, err error) error {
 		if err != nil {
 			return err
 		}
+		te.transition = t.transition
 		return te.Range(fn)
 	})
 }
 
 var (
-	errFieldSelector = errors.New("field selector does not exist")
-	errOmitNil       = errors.New("omit nil")
+	// ErrExprSelector the expression selector does not exist
+	ErrExprSelector = errors.New("expression selector does not exist")
+	// ErrFieldSelector the field selector does not exist
+	ErrFieldSelector = errors.New("field selector does not exist")
+	// ErrOmitNil the parent field is nil and omitted by the '?' tag
+	ErrOmitNil = errors.New("omit nil")
 )
 
 func (t *TagExpr) checkout(fs string) (*TagExpr, error) {
 	if fs == "" {
 		return t, nil
 	}
+	t.subLock.RLock()
 	subTagExpr, ok := t.sub[fs]
+	t.subLock.RUnlock()
 	if ok {
 		if subTagExpr == nil {
-			return nil, errOmitNil
+			return nil, ErrOmitNil
 		}
 		return subTagExpr, nil
 	}
 	f, ok := t.s.fields[fs]
 	if !ok {
-		return nil, errFieldSelector
+		return nil, ErrFieldSelector
 	}
 	ptr := f.getElemPtr(t.ptr)
-	if f.tagOp == tagOmitNil && ptr == nil {
-		t.sub[fs] = nil
-		return nil, errOmitNil
+	if f.tagOp != tagOmitNil || ptr != nil {
+		subTagExpr = f.origin.newTagExpr(ptr, t.path)
+		subTagExpr.transition = t.transition
+		if t.old != nil {
+			subTagExpr.old = f.getElemPtr(t.old)
+		}
+	}
+	t.subLock.Lock()
+	if t.sub == nil {
+		t.sub = make(map[string]*TagExpr, 8)
+	}
+	if had, ok := t.sub[fs]; ok {
+		// checked out by another goroutine
+		subTagExpr = had
+	} else {
+		t.sub[fs] = subTagExpr
+	}
+	t.subLock.Unlock()
+	if subTagExpr == nil {
+		return nil, ErrOmitNil
 	}
-	subTagExpr = f.origin.newTagExpr(ptr, t.path)
-	t.sub[fs] = subTagExpr
 	return subTagExpr, nil
 }
 
 func (t *TagExpr) getValue(fieldSelector string, subFields []interface{}) (v interface{}) {
+	if t.dynamic != nil {
+		return t.dynamic.getValue(fieldSelector, subFields)
+	}
+	return t.fieldValue(t.ptr, fieldSelector, subFields)
+}
+
+// getOldValue returns the field value of the old instance, see VM.RunTransition.
+// NOTE:
+//  If it is not a transition, return the current value;
+//  If there is no paired old instance in the transition, return nil.
+func (t *TagExpr) getOldValue(fieldSelector string, subFields []interface{}) interface{} {
+	if t.dynamic != nil || !t.transition {
+		return t.getValue(fieldSelector, subFields)
+	}
+	if t.old == nil {
+		return nil
+	}
+	return t.fieldValue(t.old, fieldSelector, subFields)
+}
+
+func (t *TagExpr) fieldValue(ptr unsafe.Pointer, fieldSelector string, subFields []interface{}) (v interface{}) {
 	f := t.s.fields[fieldSelector]
 	if f == nil {
 		return nil
@@ -1011,14 +1301,28 @@ func (t *TagExpr) getValue(fieldSelector string, subFields []interface{}) (v int
 	if f.valueGetter == nil {
 		return nil
 	}
-	v = f.valueGetter(t.ptr)
+	v = f.valueGetter(ptr)
 	if v == nil {
 		return nil
 	}
 	if len(subFields) == 0 {
 		return v
 	}
-	vv := reflect.ValueOf(v)
+	return indexValue(v, subFields)
+}
+
+// indexValue returns the element of the value by the subscripts, e.g. $[0]['k'].
+func indexValue(v interface{}, subFields []interface{}) interface{} {
+	raw := indexReflectValue(reflect.ValueOf(v), subFields)
+	vv := raw
+	for vv.Kind() == reflect.Ptr || vv.Kind() == reflect.Interface {
+		vv = vv.Elem()
+	}
+	return anyValueGetter(raw, vv)
+}
+
+// indexReflectValue returns the element of the value by the subscripts, or the invalid value if not found.
+func indexReflectValue(vv reflect.Value, subFields []interface{}) reflect.Value {
 	var kind reflect.Kind
 	for i, k := range subFields {
 		kind = vv.Kind()
@@ -1030,45 +1334,41 @@ func (t *TagExpr) getValue(fieldSelector string, subFields []interface{}) (v int
 		case reflect.Slice, reflect.Array, reflect.String:
 			if float, ok := k.(float64); ok {
 				idx := int(float)
-				if idx >= vv.Len() {
-					return nil
+				if idx < 0 || idx >= vv.Len() {
+					return reflect.Value{}
 				}
 				vv = vv.Index(idx)
 			} else {
-				return nil
+				return reflect.Value{}
 			}
 		case reflect.Map:
 			k := safeConvert(reflect.ValueOf(k), vv.Type().Key())
 			if !k.IsValid() {
-				return nil
+				return reflect.Value{}
 			}
 			vv = vv.MapIndex(k)
 		case reflect.Struct:
 			if float, ok := k.(float64); ok {
 				idx := int(float)
 				if idx < 0 || idx >= vv.NumField() {
-					return nil
+					return reflect.Value{}
 				}
 				vv = vv.Field(idx)
 			} else if str, ok := k.(string); ok {
 				vv = vv.FieldByName(str)
 			} else {
-				return nil
+				return reflect.Value{}
 			}
 		default:
 			if i < len(subFields)-1 {
-				return nil
+				return reflect.Value{}
 			}
 		}
 		if !vv.IsValid() {
-			return nil
+			return reflect.Value{}
 		}
 	}
-	raw := vv
-	for vv.Kind() == reflect.Ptr || vv.Kind() == reflect.Interface {
-		vv = vv.Elem()
-	}
-	return anyValueGetter(raw, vv)
+	return vv
 }
 
 func safeConvert(v reflect.Value, t reflect.Type) reflect.Value {
@@ -1091,35 +1391,55 @@ func splitFieldSelector(selector string) (dir, base string) {
 }
 
 func getFloat64(kind reflect.Kind, p unsafe.Pointer) interface{} {
+	var f float64
 	switch kind {
 	case reflect.Float32:
-		return float64(*(*float32)(p))
+		f = float64(*(*float32)(p))
 	case reflect.Float64:
-		return *(*float64)(p)
+		f = *(*float64)(p)
 	case reflect.Int:
-		return float64(*(*int)(p))
+		f = float64(*(*int)(p))
 	case reflect.Int8:
-		return float64(*(*int8)(p))
+		f = float64(*(*int8)(p))
 	case reflect.Int16:
-		return float64(*(*int16)(p))
+		f = float64(*(*int16)(p))
 	case reflect.Int32:
-		return float64(*(*int32)(p))
+		f = float64(*(*int32)(p))
 	case reflect.Int64:
-		return float64(*(*int64)(p))
+		f = float64(*(*int64)(p))
 	case reflect.Uint:
-		return float64(*(*uint)(p))
+		f = float64(*(*uint)(p))
 	case reflect.Uint8:
-		return float64(*(*uint8)(p))
+		f = float64(*(*uint8)(p))
 	case reflect.Uint16:
-		return float64(*(*uint16)(p))
+		f = float64(*(*uint16)(p))
 	case reflect.Uint32:
-		return float64(*(*uint32)(p))
+		f = float64(*(*uint32)(p))
 	case reflect.Uint64:
-		return float64(*(*uint64)(p))
+		f = float64(*(*uint64)(p))
 	case reflect.Uintptr:
-		return float64(*(*uintptr)(p))
+		f = float64(*(*uintptr)(p))
+	default:
+		return nil
 	}
-	return nil
+	return boxFloat64(f)
+}
+
+// boxedFloat64s the boxed small integers, see boxFloat64
+var boxedFloat64s = func() (a [1024 + 128]interface{}) {
+	for i := range a {
+		a[i] = float64(i - 128)
+	}
+	return
+}()
+
+// boxFloat64 returns the number as interface{},
+// without allocation for the integers in [-128, 1024), such as most lengths, counts and ages.
+func boxFloat64(f float64) interface{} {
+	if i := int(f); float64(i) == f && i >= -128 && i < 1024 && (f != 0 || !math.Signbit(f)) {
+		return boxedFloat64s[i+128]
+	}
+	return f
 }
 
 func anyValueGetter(raw, elem reflect.Value) interface{} {
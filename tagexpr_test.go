@@ -15,31 +15,51 @@
 package tagexpr
 
 import (
+	"errors"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+type benchS struct {
+	b string `bench:"len($)>0"`
+}
+
+type benchT struct {
+	a int `bench:"$%3"`
+	s benchS
+}
+
 func BenchmarkTagExpr(b *testing.B) {
-	type T struct {
-		a int `bench:"$%3"`
-	}
+	benchmarkTagExpr(b, false)
+}
+
+// BenchmarkTagExprRelease the same as BenchmarkTagExpr, but releases the TagExpr after use.
+func BenchmarkTagExprRelease(b *testing.B) {
+	benchmarkTagExpr(b, true)
+}
+
+func benchmarkTagExpr(b *testing.B, release bool) {
 	vm := New("bench")
-	vm.MustRun(new(T)) // warm up
+	vm.MustRun(new(benchT)) // warm up
 	b.ReportAllocs()
 	b.ResetTimer()
-	var t = &T{10}
+	var t = &benchT{10, benchS{"x"}}
 	for i := 0; i < b.N; i++ {
 		tagExpr, err := vm.Run(t)
 		if err != nil {
 			b.FailNow()
 		}
-		if tagExpr.EvalFloat("a") != 1 {
+		if tagExpr.EvalFloat("a") != 1 || !tagExpr.EvalBool("s.b") {
 			b.FailNow()
 		}
+		if release {
+			tagExpr.Release()
+		}
 	}
 }
 
@@ -843,3 +863,175 @@ func TestIssue4(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+func TestEvalE(t *testing.T) {
+	type Sub struct {
+		X int `te:"$;n:nil;s:'s';i:int($);e:(Y)$"`
+		Y []int
+	}
+	type T struct {
+		A  *Sub `te:"?"`
+		B  *Sub
+		C  int `te:"$>0"`
+		S2 Sub
+	}
+	vm := New("te")
+	te := vm.MustRun(&T{B: &Sub{X: 2, Y: []int{1}}, C: 1})
+
+	_, err := te.EvalE("Z")
+	assert.Equal(t, ErrExprSelector, err)
+	_, err = te.EvalE("A.X")
+	assert.True(t, errors.Is(err, ErrOmitNil), err)
+	assert.Nil(t, te.Eval("A.X"))
+
+	v, err := te.EvalE("B.X")
+	assert.NoError(t, err)
+	assert.Equal(t, NumberKind, v.Kind())
+	assert.Equal(t, 2.0, v.Float())
+	assert.Equal(t, int64(2), v.Int())
+	assert.True(t, v.Bool())
+	v, err = te.EvalE("B.X@n")
+	assert.NoError(t, err)
+	assert.True(t, v.IsNil())
+	assert.Equal(t, NilKind, v.Kind())
+	v, _ = te.EvalE("B.X@s")
+	assert.Equal(t, StringKind, v.Kind())
+	assert.Equal(t, "s", v.String())
+	assert.Equal(t, 0.0, v.Float())
+	v, _ = te.EvalE("B.X@i")
+	assert.Equal(t, IntegerKind, v.Kind())
+	assert.Equal(t, 2.0, v.Float())
+	v, _ = te.EvalE("B.X@e")
+	assert.Equal(t, OtherKind, v.Kind())
+	v, _ = te.EvalE("C")
+	assert.Equal(t, BoolKind, v.Kind())
+	assert.Equal(t, "bool", v.Kind().String())
+	assert.Equal(t, ErrorKind, NewValue(errors.New("x")).Kind())
+	assert.Equal(t, ListKind, NewValue([]interface{}{}).Kind())
+}
+
+func TestConcurrentEval(t *testing.T) {
+	type Sub struct {
+		X int      `te:"$>0;r:regexp('^\\d+$', sprintf('%d', int($)))"`
+		Y []string `te:"range($, len(#v)>0)"`
+	}
+	type T struct {
+		A  *Sub `te:"?"`
+		B  *Sub
+		C  Sub
+		D  []*Sub
+		Ms string `te:"tmpl('{B.X}:{$}')"`
+	}
+	vm := New("te")
+	obj := &T{
+		B: &Sub{X: 1, Y: []string{"a"}},
+		C: Sub{X: 2},
+		D: []*Sub{{X: 3}, {X: 0}},
+	}
+	want := make(map[string]interface{})
+	for _, es := range []string{"A.X", "B.X", "B.X@r", "B.Y", "C.X", "C.X@r", "Ms"} {
+		want[es] = vm.MustRun(obj).Eval(es)
+	}
+	te := vm.MustRun(obj)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for es, v := range want {
+				assert.Equal(t, v, te.Eval(es), es)
+			}
+			_, found := te.Field("C.X")
+			assert.True(t, found)
+			var count int
+			assert.NoError(t, te.Range(func(eh *ExprHandler) error {
+				count++
+				eh.Eval()
+				return nil
+			}))
+			assert.Equal(t, 13, count)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRelease(t *testing.T) {
+	type Sub struct {
+		X int `te:"$"`
+	}
+	type T struct {
+		A *Sub `te:"?"`
+		B Sub
+	}
+	vm := New("te")
+	for i := 0; i < 10; i++ {
+		te := vm.MustRun(&T{B: Sub{X: i}})
+		assert.Nil(t, te.Eval("A.X"))
+		assert.Equal(t, float64(i), te.Eval("B.X"))
+		te.Release()
+		te = vm.MustRun(&T{A: &Sub{X: -i}})
+		assert.Equal(t, float64(-i), te.Eval("A.X"))
+		assert.Equal(t, 0.0, te.Eval("B.X"))
+		te.Release()
+		// the handlers are rebuilt for the reused TagExpr
+		for _, obj := range []*T{{B: Sub{X: i}}, {A: &Sub{X: -i}}} {
+			te = vm.MustRun(obj)
+			var paths []string
+			var values []interface{}
+			assert.NoError(t, te.Range(func(eh *ExprHandler) error {
+				paths = append(paths, eh.Path())
+				values = append(values, eh.Eval())
+				return nil
+			}))
+			if obj.A == nil {
+				assert.Equal(t, []string{"B.X"}, paths)
+				assert.Equal(t, []interface{}{float64(i)}, values)
+			} else {
+				assert.Equal(t, []string{"A.X", "B.X"}, paths)
+				assert.Equal(t, []interface{}{float64(-i), 0.0}, values)
+			}
+			te.Release()
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	type Sub struct {
+		X int `te:"len("`
+		Y int `te:"$>0"`
+	}
+	type Good struct {
+		A int `te:"$>0"`
+	}
+	type Bad struct {
+		A int `te:"$>0;x:"`
+		B int `te:"(("`
+		C []*Sub
+		D map[string]Sub `te:"-"`
+	}
+	type BadTmpl struct {
+		A string `te:"tmpl('{Z}')"`
+	}
+	vm := New("te")
+	assert.NoError(t, vm.Register(Good{}, new(Good), reflect.TypeOf(Good{})))
+	err := vm.Register(Good{}, &Bad{}, reflect.ValueOf(BadTmpl{}), 1, nil)
+	errs, ok := err.(TagErrors)
+	if !assert.True(t, ok, err) {
+		return
+	}
+	assert.Len(t, errs, 6)
+	assert.Equal(t, "tagexpr.Bad", errs[0].Type)
+	assert.Equal(t, "A", errs[0].Field)
+	assert.Equal(t, "B", errs[1].Field)
+	assert.Equal(t, "tagexpr.Sub.X: syntax error: \"(\"", errs[2].Error())
+	assert.Equal(t, "tagexpr.BadTmpl: tmpl placeholder {Z} of tagexpr.BadTmpl.A does not resolve to a field", errs[3].Error())
+	assert.Equal(t, "int: unsupport type: int", errs[4].Error())
+	assert.Equal(t, "<nil>: unsupport data: nil", errs[5].Error())
+	t.Log(err)
+
+	// registered
+	_, err = vm.Run(&Good{})
+	assert.NoError(t, err)
+	_, err = vm.Run(&Bad{})
+	assert.Error(t, err)
+}
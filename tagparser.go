@@ -2,8 +2,12 @@ package tagexpr
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"unicode"
+
+	"github.com/bytedance/go-tagexpr/v2/internal/exprtree"
 )
 
 type namedTagExpr struct {
@@ -12,21 +16,11 @@ type namedTagExpr struct {
 }
 
 const (
-	tagOmit    = "-"
-	tagOmitNil = "?"
+	tagOmit    = exprtree.TagOmit
+	tagOmitNil = exprtree.TagOmitNil
 )
 
-func (f *fieldVM) parseExprs(tag string) error {
-	switch tag {
-	case tagOmit, tagOmitNil:
-		f.tagOp = tag
-		return nil
-	}
-
-	kvs, err := parseTag(tag)
-	if err != nil {
-		return err
-	}
+func (f *fieldVM) parseExprs(kvs map[string]string) error {
 	exprSelectorPrefix := f.structField.Name
 
 	for exprSelector, exprString := range kvs {
@@ -46,6 +40,108 @@ func (f *fieldVM) parseExprs(tag string) error {
 	return nil
 }
 
+// TagConflict the conflict between the tags of the same field,
+// that is, the same expression name with different expressions,
+// or the tag operator '-' or '?' with the other tag operator or expressions.
+type TagConflict struct {
+	// Field the struct field
+	Field reflect.StructField
+	// ExprName the expression name, empty if the tag operator conflicts
+	ExprName string
+	// TagNames the tag names in order of precedence, or the rule locations for the conflicts with the rules,
+	// which are the source names and type/field paths, see RuleError.Location
+	TagNames [2]string
+	// Values the expression strings or tag operators in order of precedence,
+	// the expressions are normalized for the conflicts with the rules, see Expr.String
+	Values [2]string
+}
+
+// Error implements error interface.
+func (c *TagConflict) Error() string {
+	what := "tag operator"
+	if c.ExprName != "" {
+		what = fmt.Sprintf("expression %q", c.ExprName)
+	}
+	return fmt.Sprintf("tag conflict: %s of field %s: %s:%q and %s:%q",
+		what, c.Field.Name, c.TagNames[0], c.Values[0], c.TagNames[1], c.Values[1])
+}
+
+// readTags reads and merges the tags of the field by expression name.
+// NOTE:
+//  The earlier tag name takes precedence;
+//  When a conflict is accepted by the conflict handler, the value of the earlier tag name is used.
+func (vm *VM) readTags(structField reflect.StructField) (tagOp string, kvs map[string]string, err error) {
+	kvs = make(map[string]string)
+	if len(vm.tagNames) == 1 {
+		tag := structField.Tag.Get(vm.tagNames[0])
+		switch tag {
+		case tagOmit, tagOmitNil:
+			return tag, kvs, nil
+		}
+		kvs, err = parseTag(tag)
+		return "", kvs, err
+	}
+	from := make(map[string]string, 4) // expression name or "" of tag operator -> tag name
+	for _, tagName := range vm.tagNames {
+		tag, ok := structField.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		var one map[string]string
+		switch tag {
+		case tagOmit, tagOmitNil:
+			one = map[string]string{"": tag}
+		default:
+			one, err = parseTag(tag)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		for _, name := range sortedKeys(one) {
+			val := one[name]
+			conflict := &TagConflict{Field: structField, ExprName: name, TagNames: [2]string{"", tagName}, Values: [2]string{"", val}}
+			if had, ok := kvs[name]; ok {
+				if had == val {
+					continue
+				}
+				conflict.TagNames[0], conflict.Values[0] = from[name], had
+			} else if op, ok := kvs[""]; ok {
+				conflict.ExprName = ""
+				conflict.TagNames[0], conflict.Values[0] = from[""], op
+			} else if name == "" && len(kvs) > 0 {
+				first := sortedKeys(kvs)[0]
+				conflict.TagNames[0], conflict.Values[0] = from[first], kvs[first]
+			} else {
+				kvs[name] = val
+				from[name] = tagName
+				continue
+			}
+			if err = vm.handleTagConflict(conflict); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	tagOp = kvs[""]
+	delete(kvs, "")
+	return tagOp, kvs, nil
+}
+
+func (vm *VM) handleTagConflict(c *TagConflict) error {
+	if vm.tagConflictHandler == nil {
+		return c
+	}
+	return vm.tagConflictHandler(c)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func parseTag(tag string) (map[string]string, error) {
 	s := tag
 	ptr := &s
@@ -77,3 +77,68 @@ func TestTagparser(t *testing.T) {
 		}
 	}
 }
+
+func TestReadTags(t *testing.T) {
+	cases := []struct {
+		tag       reflect.StructTag
+		tagOp     string
+		expect    map[string]string
+		conflicts []string
+	}{
+		{
+			tag:    `a:"$>0" b:"x:$<10"`,
+			expect: map[string]string{"@": "$>0", "x": "$<10"},
+		}, {
+			tag:    `b:"$>0;msg:'b'" a:"$>0"`,
+			expect: map[string]string{"@": "$>0", "msg": "'b'"},
+		}, {
+			tag:       `a:"$>0;msg:'a'" b:"$>1;msg:'b'"`,
+			expect:    map[string]string{"@": "$>0", "msg": "'a'"},
+			conflicts: []string{`tag conflict: expression "@" of field F: a:"$>0" and b:"$>1"`, `tag conflict: expression "msg" of field F: a:"'a'" and b:"'b'"`},
+		}, {
+			tag:    `a:"?" b:"?"`,
+			tagOp:  "?",
+			expect: map[string]string{},
+		}, {
+			tag:       `a:"-" b:"$>0"`,
+			tagOp:     "-",
+			expect:    map[string]string{},
+			conflicts: []string{`tag conflict: tag operator of field F: a:"-" and b:"$>0"`},
+		}, {
+			tag:       `a:"$>0" b:"?"`,
+			expect:    map[string]string{"@": "$>0"},
+			conflicts: []string{`tag conflict: tag operator of field F: a:"$>0" and b:"?"`},
+		}, {
+			tag:    `c:"$>0"`,
+			expect: map[string]string{},
+		},
+	}
+	for _, c := range cases {
+		var conflicts []string
+		vm := New("a", "b").SetTagConflictHandler(func(conflict *TagConflict) error {
+			conflicts = append(conflicts, conflict.Error())
+			return nil
+		})
+		tagOp, kvs, err := vm.readTags(reflect.StructField{Name: "F", Tag: c.tag})
+		assert.NoError(t, err, c.tag)
+		assert.Equal(t, c.tagOp, tagOp, c.tag)
+		assert.Equal(t, c.expect, kvs, c.tag)
+		assert.Equal(t, c.conflicts, conflicts, c.tag)
+	}
+
+	type T struct {
+		A int `a:"$>0" b:"$>1"`
+	}
+	_, err := New("a", "b").Run(new(T))
+	conflict, ok := err.(*TagConflict)
+	if assert.True(t, ok, err) {
+		assert.Equal(t, [2]string{"a", "b"}, conflict.TagNames)
+		assert.Equal(t, [2]string{"$>0", "$>1"}, conflict.Values)
+	}
+	te := New("a", "b").SetTagConflictHandler(func(*TagConflict) error { return nil }).MustRun(&T{A: 1})
+	assert.Equal(t, true, te.Eval("A"))
+	_, err = New("a", "b").SetTagConflictHandler(func(c *TagConflict) error {
+		return c
+	}).Run(new(T))
+	assert.Error(t, err)
+}
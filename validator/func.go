@@ -34,7 +34,26 @@ func MustRegFunc(funcName string, fn func(args ...interface{}) error, force ...b
 //  The go number types always are float64;
 //  The go string types always are string.
 func RegFunc(funcName string, fn func(args ...interface{}) error, force ...bool) error {
-	return tagexpr.RegFunc(funcName, func(args ...interface{}) interface{} {
+	return RegFuncWithArity(funcName, 0, -1, fn, force...)
+}
+
+// MustRegFuncWithArity registers validator function expression that takes @minArgs to @maxArgs arguments.
+// NOTE:
+//  panic if exist error;
+//  The others are the same as RegFuncWithArity.
+func MustRegFuncWithArity(funcName string, minArgs, maxArgs int, fn func(args ...interface{}) error, force ...bool) {
+	err := RegFuncWithArity(funcName, minArgs, maxArgs, fn, force...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RegFuncWithArity registers validator function expression that takes @minArgs to @maxArgs arguments.
+// NOTE:
+//  If @maxArgs < 0, the number of arguments is unlimited;
+//  The others are the same as RegFunc.
+func RegFuncWithArity(funcName string, minArgs, maxArgs int, fn func(args ...interface{}) error, force ...bool) error {
+	return tagexpr.RegFuncWithArity(funcName, minArgs, maxArgs, func(args ...interface{}) interface{} {
 		err := fn(args...)
 		if err == nil {
 			// nil defaults to false, so returns true
@@ -47,7 +66,7 @@ func RegFunc(funcName string, fn func(args ...interface{}) error, force ...bool)
 func init() {
 	var pattern = "^([A-Za-z0-9_\\-\\.\u4e00-\u9fa5])+\\@([A-Za-z0-9_\\-\\.])+\\.([A-Za-z]{2,8})$"
 	emailRegexp := regexp.MustCompile(pattern)
-	MustRegFunc("email", func(args ...interface{}) error {
+	MustRegFuncWithArity("email", 1, 1, func(args ...interface{}) error {
 		if len(args) != 1 {
 			return errors.New("number of parameters of email function is not one")
 		}
@@ -66,7 +85,7 @@ func init() {
 
 func init() {
 	// phone: defaultRegion is 'CN'
-	MustRegFunc("phone", func(args ...interface{}) error {
+	MustRegFuncWithArity("phone", 1, 2, func(args ...interface{}) error {
 		var numberToParse, defaultRegion string
 		var ok bool
 		switch len(args) {
@@ -102,7 +121,7 @@ func init() {
 
 func init() {
 	// in: Check if the first parameter is one of the enumerated parameters
-	MustRegFunc("in", func(args ...interface{}) error {
+	MustRegFuncWithArity("in", 2, -1, func(args ...interface{}) error {
 		switch len(args) {
 		case 0:
 			return nil
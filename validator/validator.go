@@ -20,6 +20,7 @@ import (
 	"io"
 	"reflect"
 	"strings"
+	"sync"
 	_ "unsafe"
 
 	tagexpr "github.com/bytedance/go-tagexpr/v2"
@@ -37,12 +38,16 @@ const (
 type Validator struct {
 	vm         *tagexpr.VM
 	errFactory func(failPath, msg string) error
+	explain    bool
 }
 
 // New creates a struct fields validator.
-func New(tagName string) *Validator {
+// NOTE:
+//  If moreTagNames are specified, the tags are merged by expression name and the earlier tag name takes precedence,
+//  see tagexpr.New.
+func New(tagName string, moreTagNames ...string) *Validator {
 	v := &Validator{
-		vm:         tagexpr.New(tagName),
+		vm:         tagexpr.New(append([]string{tagName}, moreTagNames...)...),
 		errFactory: defaultErrorFactory,
 	}
 	return v
@@ -57,75 +62,164 @@ func (v *Validator) VM() *tagexpr.VM {
 // NOTE:
 //  If checkAll=true, validate all the error.
 func (v *Validator) Validate(value interface{}, checkAll ...bool) error {
-	var all bool
-	if len(checkAll) > 0 {
-		all = checkAll[0]
-	}
-	var errs = make([]error, 0, 8)
-	err := v.vm.RunAny(value, func(te *tagexpr.TagExpr, err error) error {
-		if err != nil {
-			errs = append(errs, err)
-			if all {
-				return nil
+	vd := v.newValidation(nil, checkAll)
+	return vd.result(v.vm.RunAny(value, vd.onTagExpr))
+}
+
+// ValidateTransition validates the new value of the update,
+// whose expressions can read the field values of the old value by $old and (X)$old,
+// e.g. `vd:"$ == $old"` for the immutable field.
+// NOTE:
+//  The old and new values must be of the same struct type, see tagexpr.VM.RunTransition;
+//  If oldValue is nil, or a struct has no paired old value, its $old is nil, e.g. `vd:"$old == nil || $ == $old"`;
+//  If checkAll=true, validate all the error.
+func (v *Validator) ValidateTransition(oldValue, newValue interface{}, checkAll ...bool) error {
+	vd := v.newValidation(nil, checkAll)
+	return vd.result(vd.onTagExpr(v.vm.RunTransition(oldValue, newValue)))
+}
+
+// ValidateFields validates only the expressions that live on or read the changed fields,
+// including the cross-field expressions.
+// NOTE:
+//  The changed selectors are the field selectors, e.g. 'A', 'A.B';
+//  A field also matches its parent and child fields, and the elements of the matched slice, array, map and interface fields are validated;
+//  If changedSelectors is empty, return nil.
+func (v *Validator) ValidateFields(value interface{}, changedSelectors ...string) error {
+	if len(changedSelectors) == 0 {
+		return nil
+	}
+	vd := v.newValidation(changedSelectors, nil)
+	return vd.result(v.vm.RunAny(value, vd.onTagExpr))
+}
+
+// validation the state of a validation, which is pooled to avoid the allocations per call.
+type validation struct {
+	v *Validator
+	// if changedSelectors!=nil, only validate the expressions that live on or read the fields
+	changedSelectors []string
+	all              bool
+	errs             []error
+	nilParentFields  map[string]bool
+	// the method values bound once, see validationPool
+	onTagExpr func(*tagexpr.TagExpr, error) error
+	onExpr    func(*tagexpr.ExprHandler) error
+}
+
+var validationPool = sync.Pool{
+	New: func() interface{} {
+		vd := new(validation)
+		vd.onTagExpr = vd.tagExpr
+		vd.onExpr = vd.expr
+		return vd
+	},
+}
+
+func (v *Validator) newValidation(changedSelectors []string, checkAll []bool) *validation {
+	vd := validationPool.Get().(*validation)
+	vd.v = v
+	vd.changedSelectors = changedSelectors
+	vd.all = len(checkAll) > 0 && checkAll[0]
+	return vd
+}
+
+// result returns the validation error, and puts the validation back to the pool.
+func (vd *validation) result(err error) error {
+	if err == io.EOF || err == nil {
+		switch len(vd.errs) {
+		case 0:
+			err = nil
+		case 1:
+			err = vd.errs[0]
+		default:
+			var errStr string
+			for _, e := range vd.errs {
+				errStr += e.Error() + "\t"
 			}
-			return io.EOF
+			err = errors.New(errStr[:len(errStr)-1])
 		}
-		nilParentFields := make(map[string]bool, 16)
-		err = te.Range(func(eh *tagexpr.ExprHandler) error {
-			if strings.Contains(eh.StringSelector(), tagexpr.ExprNameSeparator) {
-				return nil
-			}
-			r := eh.Eval()
-			if r == nil {
-				return nil
-			}
-			rerr, ok := r.(error)
-			if !ok && tagexpr.FakeBool(r) {
-				return nil
-			}
-			// Ignore this error if the value of the parent is nil
-			if pfs, ok := eh.ExprSelector().ParentField(); ok {
-				if nilParentFields[pfs] {
-					return nil
-				}
-				if fh, ok := eh.TagExpr().Field(pfs); ok {
-					v := fh.Value(false)
-					if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
-						nilParentFields[pfs] = true
-						return nil
-					}
+	}
+	for i := range vd.errs {
+		vd.errs[i] = nil
+	}
+	vd.errs = vd.errs[:0]
+	for k := range vd.nilParentFields {
+		delete(vd.nilParentFields, k)
+	}
+	vd.v = nil
+	vd.changedSelectors = nil
+	validationPool.Put(vd)
+	return err
+}
+
+// tagExpr validates the expressions of the tag expression handler.
+func (vd *validation) tagExpr(te *tagexpr.TagExpr, err error) error {
+	if err != nil {
+		vd.errs = append(vd.errs, err)
+		if vd.all {
+			return nil
+		}
+		return io.EOF
+	}
+	defer te.Release()
+	// nil parent fields only apply to the current TagExpr
+	for k := range vd.nilParentFields {
+		delete(vd.nilParentFields, k)
+	}
+	if vd.changedSelectors != nil {
+		err = te.RangeDependents(vd.changedSelectors, vd.onExpr)
+	} else {
+		err = te.Range(vd.onExpr)
+	}
+	if err != nil && !vd.all {
+		return err
+	}
+	return nil
+}
+
+// expr validates the expression.
+func (vd *validation) expr(eh *tagexpr.ExprHandler) error {
+	if strings.Contains(eh.StringSelector(), tagexpr.ExprNameSeparator) {
+		return nil
+	}
+	r := eh.Eval()
+	if r == nil {
+		return nil
+	}
+	rerr, ok := r.(error)
+	if !ok && tagexpr.FakeBool(r) {
+		return nil
+	}
+	// Ignore this error if the value of the parent is nil
+	if pfs, ok := eh.ExprSelector().ParentField(); ok {
+		if vd.nilParentFields[pfs] {
+			return nil
+		}
+		if fh, ok := eh.TagExpr().Field(pfs); ok {
+			v := fh.Value(false)
+			if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+				if vd.nilParentFields == nil {
+					vd.nilParentFields = make(map[string]bool, 16)
 				}
-			}
-			msg := eh.TagExpr().EvalString(eh.StringSelector() + tagexpr.ExprNameSeparator + ErrMsgExprName)
-			if msg == "" && rerr != nil {
-				msg = rerr.Error()
-			}
-			errs = append(errs, v.errFactory(eh.Path(), msg))
-			if all {
+				vd.nilParentFields[pfs] = true
 				return nil
 			}
-			return io.EOF
-		})
-		if err != nil && !all {
-			return err
 		}
-		return nil
-	})
-	if err != io.EOF && err != nil {
-		return err
 	}
-	switch len(errs) {
-	case 0:
-		return nil
-	case 1:
-		return errs[0]
-	default:
-		var errStr string
-		for _, e := range errs {
-			errStr += e.Error() + "\t"
+	msg := eh.TagExpr().EvalString(eh.StringSelector() + tagexpr.ExprNameSeparator + ErrMsgExprName)
+	if msg == "" && rerr != nil {
+		msg = rerr.Error()
+	}
+	verr := vd.v.errFactory(eh.Path(), msg)
+	if vd.v.explain {
+		if e, ok := verr.(*Error); ok {
+			e.FailClause = eh.Explain().FailedClause()
 		}
-		return errors.New(errStr[:len(errStr)-1])
 	}
+	vd.errs = append(vd.errs, verr)
+	if vd.all {
+		return nil
+	}
+	return io.EOF
 }
 
 // SetErrorFactory customizes the factory of validation error.
@@ -139,9 +233,19 @@ func (v *Validator) SetErrorFactory(errFactory func(failPath, msg string) error)
 	return v
 }
 
+// SetExplain sets whether to attach the failed sub-clause of the expression to the *Error.
+// NOTE:
+//  The failed expression is evaluated again to trace it.
+func (v *Validator) SetExplain(enable bool) *Validator {
+	v.explain = enable
+	return v
+}
+
 // Error validate error
 type Error struct {
 	FailPath, Msg string
+	// FailClause the failed sub-clause of the expression, only set when SetExplain(true)
+	FailClause *tagexpr.ExprTrace
 }
 
 // Error implements error interface.
@@ -279,6 +279,19 @@ func TestStructSliceMap(t *testing.T) {
 	assert.EqualError(t, err, "invalid parameter: A{v for k=x}.f.g\tinvalid parameter: B[0]{v for k=y}.f.g\tinvalid parameter: C{v for k=z}[0]{v for k=zz}.f.g")
 }
 
+func TestNilParentPerElement(t *testing.T) {
+	type Sub struct {
+		X int `vd:"$>0"`
+	}
+	type T struct {
+		P *Sub
+	}
+	err := vd.Validate([]*T{{P: &Sub{X: 0}}, {}})
+	assert.EqualError(t, err, "invalid parameter: [0].P.X")
+	err = vd.Validate(map[string]*T{"a": {}, "b": {P: &Sub{X: 0}}}, true)
+	assert.EqualError(t, err, "invalid parameter: {v for k=b}.P.X")
+}
+
 func TestIssue30(t *testing.T) {
 	type TStruct struct {
 		TOk string `vd:"gt($,'0') && gt($, '1')" json:"t_ok"`
@@ -299,3 +312,154 @@ func TestIssue31(t *testing.T) {
 	assert.EqualError(t, vd.Validate(&TStruct{A: []int32{1}}), "syntax error: \"($ != nil && range($, in(#v, 1, 2, 3))\"")
 	assert.EqualError(t, vd.Validate(&TStruct{A: []int32{1}}), "syntax error: \"($ != nil && range($, in(#v, 1, 2, 3))\"")
 }
+
+func TestExplain(t *testing.T) {
+	type T struct {
+		Name string `vd:"($!='Alice'||(Age)$==18) && regexp('^\\w*$')"`
+		Age  int
+	}
+	v := vd.New("vd").SetExplain(true)
+	err := v.Validate(&T{Name: "Alice", Age: 20})
+	assert.EqualError(t, err, "invalid parameter: Name")
+	verr := err.(*vd.Error)
+	assert.Equal(t, "$ != 'Alice' || (Age)$ == 18", verr.FailClause.Source)
+
+	err = vd.New("vd").Validate(&T{Name: "Alice", Age: 20})
+	assert.Nil(t, err.(*vd.Error).FailClause)
+	assert.NoError(t, v.Validate(&T{Name: "Alice", Age: 18}))
+}
+
+func TestValidateFields(t *testing.T) {
+	type Item struct {
+		Qty int `vd:"$>0"`
+	}
+	type Addr struct {
+		City string `vd:"len($)>0"`
+	}
+	type T struct {
+		Name  string `vd:"len($)>0"`
+		Age   int    `vd:"$>=18"`
+		Min   int
+		Max   int `vd:"$>=(Min)$"`
+		Addr  Addr
+		Items []Item
+	}
+	v := vd.New("vd")
+	obj := &T{Age: 20, Min: 10, Max: 5, Items: []Item{{Qty: 0}}}
+	assert.EqualError(t, v.Validate(obj), "invalid parameter: Name")
+
+	assert.NoError(t, v.ValidateFields(obj))
+	assert.NoError(t, v.ValidateFields(obj, "Age"))
+	assert.EqualError(t, v.ValidateFields(obj, "Name"), "invalid parameter: Name")
+	// cross-field
+	assert.EqualError(t, v.ValidateFields(obj, "Min"), "invalid parameter: Max")
+	assert.EqualError(t, v.ValidateFields(obj, "Max"), "invalid parameter: Max")
+	// parent and child fields
+	assert.EqualError(t, v.ValidateFields(obj, "Addr"), "invalid parameter: Addr.City")
+	assert.EqualError(t, v.ValidateFields(obj, "Age", "Addr.City"), "invalid parameter: Addr.City")
+	// slice elements
+	assert.EqualError(t, v.ValidateFields(obj, "Items"), "invalid parameter: Items[0].Qty")
+	obj.Items[0].Qty = 1
+	assert.NoError(t, v.ValidateFields(obj, "Items", "Age"))
+}
+
+func TestValidateTransition(t *testing.T) {
+	type Item struct {
+		Price int `vd:"$old==nil || $>=$old"`
+	}
+	type Audit struct {
+		CreatedBy string `vd:"$==$old; msg:'CreatedBy is immutable'"`
+	}
+	type Doc struct {
+		Status string `vd:"$==$old || ($old=='draft' && $=='published')"`
+		Max    int    `vd:"$>=(Max)$old || (Force)$"`
+		Force  bool
+		Audit  Audit
+		Items  []Item
+	}
+	v := vd.New("vd")
+	old := &Doc{Status: "draft", Max: 5, Audit: Audit{CreatedBy: "bob"}, Items: []Item{{Price: 2}}}
+	assert.NoError(t, v.ValidateTransition(old, &Doc{Status: "published", Max: 5, Audit: Audit{CreatedBy: "bob"}, Items: []Item{{Price: 2}, {Price: 1}}}))
+	assert.EqualError(t, v.ValidateTransition(old, &Doc{Status: "archived", Max: 5, Audit: Audit{CreatedBy: "bob"}}), "invalid parameter: Status")
+	assert.EqualError(t, v.ValidateTransition(old, &Doc{Status: "draft", Max: 4, Audit: Audit{CreatedBy: "bob"}}), "invalid parameter: Max")
+	assert.NoError(t, v.ValidateTransition(*old, Doc{Status: "draft", Max: 4, Force: true, Audit: Audit{CreatedBy: "bob"}}))
+	assert.EqualError(t, v.ValidateTransition(old, &Doc{Status: "draft", Max: 5, Audit: Audit{CreatedBy: "eve"}}), "CreatedBy is immutable")
+	assert.EqualError(t, v.ValidateTransition(old, &Doc{Status: "draft", Max: 5, Audit: Audit{CreatedBy: "bob"}, Items: []Item{{Price: 1}}}), "invalid parameter: Items[0].Price")
+	assert.EqualError(t, v.ValidateTransition(old, &Doc{Status: "archived", Max: 4, Audit: Audit{CreatedBy: "eve"}}, true),
+		"invalid parameter: Status\tinvalid parameter: Max\tCreatedBy is immutable")
+
+	// without the old value, $old is nil
+	assert.EqualError(t, v.ValidateTransition(nil, &Doc{Status: "archived", Max: 1, Audit: Audit{CreatedBy: "bob"}}, true),
+		"invalid parameter: Status\tinvalid parameter: Max\tCreatedBy is immutable")
+	assert.EqualError(t, v.ValidateTransition((*Doc)(nil), &Doc{Status: "archived", Max: 1}), "invalid parameter: Status")
+	// out of the transition, $old is the current value
+	assert.NoError(t, v.Validate(&Doc{Status: "archived", Max: 1}))
+
+	// the nil old pointer on the path is not paired, nor is the interface element
+	type Ref struct {
+		Audit *Audit
+		Any   interface{}
+	}
+	assert.NoError(t, v.ValidateTransition(&Ref{Audit: &Audit{CreatedBy: "bob"}}, &Ref{Audit: &Audit{CreatedBy: "bob"}}))
+	assert.EqualError(t, v.ValidateTransition(&Ref{}, &Ref{Audit: &Audit{CreatedBy: "bob"}}), "CreatedBy is immutable")
+	assert.EqualError(t, v.ValidateTransition(&Ref{Any: &Audit{CreatedBy: "bob"}}, &Ref{Any: &Audit{CreatedBy: "bob"}}), "CreatedBy is immutable")
+
+	// the map values of the same key are paired
+	type Book struct {
+		Items map[string]*Item
+	}
+	old2 := &Book{Items: map[string]*Item{"a": {Price: 2}}}
+	assert.NoError(t, v.ValidateTransition(old2, &Book{Items: map[string]*Item{"a": {Price: 3}, "b": {Price: 1}}}))
+	assert.EqualError(t, v.ValidateTransition(old2, &Book{Items: map[string]*Item{"a": {Price: 1}}}), "invalid parameter: Items{v for k=a}.Price")
+	type Shelf struct {
+		Items map[string]Item
+	}
+	assert.NoError(t, v.ValidateTransition(&Shelf{Items: map[string]Item{"a": {Price: 2}}}, &Shelf{Items: map[string]Item{"a": {Price: 2}}}))
+	assert.EqualError(t, v.ValidateTransition(&Shelf{Items: map[string]Item{"a": {Price: 2}}}, &Shelf{Items: map[string]Item{"a": {Price: 1}}}), "invalid parameter: Items{v for k=a}.Price")
+
+	err := v.ValidateTransition(&Item{}, &Doc{})
+	assert.EqualError(t, err, "tagexpr: the old value is validator_test.Item, want validator_test.Doc")
+}
+
+func BenchmarkValidate(b *testing.B) {
+	type Addr struct {
+		City string `vd:"len($)>0"`
+	}
+	type T struct {
+		Name string `vd:"len($)>0 && len($)<32"`
+		Age  int    `vd:"$>=18"`
+		Min  int
+		Max  int `vd:"$>=(Min)$"`
+		Addr Addr
+	}
+	v := vd.New("vd")
+	obj := &T{Name: "Bob", Age: 20, Min: 1, Max: 5, Addr: Addr{City: "X"}}
+	if err := v.Validate(obj); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := v.Validate(obj); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMultiTagNames(t *testing.T) {
+	type T struct {
+		A int    `validate:"$>0" vd:"$>0"`
+		B string `vd:"len($)>0;msg:'B is empty'"`
+		C int    `validate:"$<10;msg:'C is too large'"`
+	}
+	v := vd.New("validate", "vd")
+	assert.EqualError(t, v.Validate(&T{A: 1}), "B is empty")
+	assert.EqualError(t, v.Validate(&T{A: 1, B: "b", C: 10}), "C is too large")
+	assert.EqualError(t, v.Validate(&T{B: "b"}), "invalid parameter: A")
+	assert.NoError(t, v.Validate(&T{A: 1, B: "b"}))
+
+	type Conflict struct {
+		A int `validate:"$>0" vd:"$>1"`
+	}
+	assert.EqualError(t, v.Validate(&Conflict{A: 1}), `tag conflict: expression "@" of field A: validate:"$>0" and vd:"$>1"`)
+}
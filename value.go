@@ -0,0 +1,136 @@
+// Copyright 2019 Bytedance Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tagexpr
+
+// Kind the kind of the expression value
+type Kind uint8
+
+// The kinds of the expression value
+const (
+	NilKind     Kind = iota // nil
+	NumberKind              // float64
+	IntegerKind             // Integer, e.g. the result of int()
+	StringKind              // string
+	BoolKind                // bool
+	ListKind                // []interface{}, e.g. the result of range()
+	ErrorKind               // error, e.g. the result of validator functions
+	OtherKind               // other go values, e.g. the value of composite struct field
+)
+
+var kindNames = [...]string{
+	NilKind:     "nil",
+	NumberKind:  "number",
+	IntegerKind: "integer",
+	StringKind:  "string",
+	BoolKind:    "bool",
+	ListKind:    "list",
+	ErrorKind:   "error",
+	OtherKind:   "other",
+}
+
+// String returns the kind name.
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// Value the typed value of the expression
+type Value struct {
+	v interface{}
+}
+
+// NewValue wraps the raw expression value.
+func NewValue(v interface{}) Value {
+	return Value{v: v}
+}
+
+// Interface returns the raw value.
+// NOTE:
+//  types: float64, Integer, string, bool, nil, []interface{}, error or other go values
+func (v Value) Interface() interface{} {
+	return v.v
+}
+
+// Kind returns the kind of the value.
+func (v Value) Kind() Kind {
+	switch v.v.(type) {
+	case nil:
+		return NilKind
+	case float64:
+		return NumberKind
+	case Integer:
+		return IntegerKind
+	case string:
+		return StringKind
+	case bool:
+		return BoolKind
+	case []interface{}:
+		return ListKind
+	case error:
+		return ErrorKind
+	}
+	return OtherKind
+}
+
+// IsNil returns whether the value is nil.
+func (v Value) IsNil() bool {
+	return v.v == nil
+}
+
+// Float returns the number value.
+// NOTE:
+//  If the kind is not NumberKind or IntegerKind, return 0.
+func (v Value) Float() float64 {
+	return evalFloat(v.v)
+}
+
+// Int returns the integer value.
+// NOTE:
+//  If the kind is NumberKind, return it truncated toward zero;
+//  If the kind is not NumberKind or IntegerKind, return 0.
+func (v Value) Int() int64 {
+	switch r := v.v.(type) {
+	case Integer:
+		return int64(r)
+	case float64:
+		return int64(r)
+	}
+	return 0
+}
+
+// String returns the string value.
+// NOTE:
+//  If the kind is not StringKind, return "".
+func (v Value) String() string {
+	r, _ := v.v.(string)
+	return r
+}
+
+// Bool returns the boolean value.
+// NOTE:
+//  If the value is not 0, '' or nil, return true.
+func (v Value) Bool() bool {
+	return FakeBool(v.v)
+}
+
+// Err returns the error value.
+// NOTE:
+//  If the kind is not ErrorKind, return nil.
+func (v Value) Err() error {
+	r, _ := v.v.(error)
+	return r
+}